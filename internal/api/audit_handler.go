@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/internal/service"
+)
+
+// AuditHandler handles HTTP requests related to the audit log
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler creates a new instance of AuditHandler
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// RegisterRoutes registers audit routes behind the given auth middleware
+func (h *AuditHandler) RegisterRoutes(router *gin.RouterGroup, auth gin.HandlerFunc) {
+	adminGroup := router.Group("/admin", auth)
+	{
+		adminGroup.GET("/audit", h.ListAuditEvents)
+	}
+
+	router.GET("/cars/:id/history", auth, h.GetCarHistory)
+}
+
+// ListAuditEvents handles GET /api/v1/admin/audit
+// @Summary List audit events
+// @Description Get audit events across all cars in chronological order, paginated with a cursor
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param type query string false "Event type, e.g. car.created"
+// @Param since query string false "Only events at or after this RFC3339 time"
+// @Param cursor query int false "Return events after this event ID"
+// @Param limit query int false "Number of events per page (default 50, max 500)"
+// @Success 200 {object} model.AuditEventPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /admin/audit [get]
+func (h *AuditHandler) ListAuditEvents(c *gin.Context) {
+	filter := model.AuditEventFilter{
+		EventType: c.Query("type"),
+	}
+	if !bindAuditFilter(c, &filter) {
+		return
+	}
+
+	page, err := h.auditService.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list audit events", err)
+		return
+	}
+
+	respond(c, http.StatusOK, page)
+}
+
+// GetCarHistory handles GET /api/v1/cars/:id/history
+// @Summary Get a car's history
+// @Description Get the audit events of a car in chronological order, paginated with a cursor. Deleted cars keep their history.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path int true "Car ID"
+// @Param type query string false "Event type, e.g. car.updated"
+// @Param since query string false "Only events at or after this RFC3339 time"
+// @Param cursor query int false "Return events after this event ID"
+// @Param limit query int false "Number of events per page (default 50, max 500)"
+// @Success 200 {object} model.AuditEventPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/{id}/history [get]
+func (h *AuditHandler) GetCarHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+		return
+	}
+
+	filter := model.AuditEventFilter{
+		CarID:     id,
+		EventType: c.Query("type"),
+	}
+	if !bindAuditFilter(c, &filter) {
+		return
+	}
+
+	page, err := h.auditService.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get car history", err)
+		return
+	}
+
+	respond(c, http.StatusOK, page)
+}
+
+// bindAuditFilter reads the since, cursor and limit query parameters into
+// filter, writing a 400 and returning false when one is malformed
+func bindAuditFilter(c *gin.Context, filter *model.AuditEventFilter) bool {
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			handleError(c, http.StatusBadRequest, "Invalid since time, expected RFC3339", err)
+			return false
+		}
+		filter.Since = &t
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || afterID < 0 {
+			handleError(c, http.StatusBadRequest, "Invalid cursor", err)
+			return false
+		}
+		filter.AfterID = afterID
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			handleError(c, http.StatusBadRequest, "Invalid limit", err)
+			return false
+		}
+		filter.Limit = n
+	}
+
+	return true
+}
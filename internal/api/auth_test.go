@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signToken signs an HS256 token with the given role claim
+func signToken(t *testing.T, secret, role string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "role": role}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	return token
+}
+
+func TestRequireAdmin(t *testing.T) {
+	const secret = "test-secret"
+
+	tests := []struct {
+		name       string
+		secret     string
+		token      string
+		wantStatus int
+	}{
+		{name: "admin", secret: secret, token: signToken(t, secret, adminRole), wantStatus: http.StatusOK},
+		{name: "missing token", secret: secret, wantStatus: http.StatusUnauthorized},
+		{name: "wrong secret", secret: secret, token: signToken(t, "other-secret", adminRole), wantStatus: http.StatusUnauthorized},
+		{name: "not an admin", secret: secret, token: signToken(t, secret, "viewer"), wantStatus: http.StatusForbidden},
+		{name: "no secret configured", secret: "", token: signToken(t, "", adminRole), wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/admin", RequireAdmin(tt.secret), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package api
 
 import (
-	"database/sql"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/username/go-car-service/internal/errs"
 	"github.com/username/go-car-service/internal/model"
 	"github.com/username/go-car-service/internal/service"
 	"github.com/username/go-car-service/pkg/logger"
@@ -15,25 +23,63 @@ import (
 // CarHandler handles HTTP requests related to cars
 type CarHandler struct {
 	carService service.CarService
+	strictJSON bool
+	// carsPath is the full path of the cars routes, set when they are registered
+	carsPath string
 }
 
-// NewCarHandler creates a new instance of CarHandler
-func NewCarHandler(carService service.CarService) *CarHandler {
-	return &CarHandler{carService: carService}
+// NewCarHandler creates a new instance of CarHandler. With strictJSON, car
+// payloads carrying fields the API does not know are rejected.
+func NewCarHandler(carService service.CarService, strictJSON bool) *CarHandler {
+	return &CarHandler{carService: carService, strictJSON: strictJSON}
 }
 
-// RegisterRoutes registers car routes
-func (h *CarHandler) RegisterRoutes(router *gin.RouterGroup) {
+// RegisterRoutes registers car routes. Admin-only routes are put behind the given auth middleware.
+func (h *CarHandler) RegisterRoutes(router *gin.RouterGroup, auth gin.HandlerFunc) {
 	carsGroup := router.Group("/cars")
+	h.carsPath = carsGroup.BasePath()
 	{
 		carsGroup.GET("", h.GetAllCars)
-		carsGroup.GET("/:id", h.GetCarByID)
+		carsGroup.GET("/deleted", auth, h.GetDeletedCars)
+		carsGroup.GET("/summary", h.GetCatalogSummary)
+		carsGroup.GET("/schema", h.GetCarSchema)
+		carsGroup.GET("/export.csv", h.ExportCarsCSV)
+		carsGroup.GET("/stats/brands-by-value", h.GetBrandsByValue)
+		carsGroup.GET("/stats/by-brand", h.GetStatsByBrand)
+		carsGroup.GET("/brands", h.ListBrands)
+		carsGroup.GET("/search", h.SearchCars)
+		carsGroup.GET("/filter", h.FilterCars)
+		carsGroup.GET("/featured/random", h.GetRandomFeaturedCar)
+		carsGroup.GET("/count", h.CountCars)
+		carsGroup.GET("/recent", h.GetRecentCars)
+		carsGroup.GET("/extremes", h.GetCarExtremes)
+		carsGroup.GET("/batch", h.GetCarsByIDs)
+		carsGroup.GET("/:id", requireAdminIf(auth, includesDeleted), h.GetCarByID)
+		carsGroup.HEAD("/:id", h.HeadCarByID)
+		carsGroup.GET("/:id/related", h.GetRelatedCars)
 		carsGroup.GET("/name/:name", h.GetCarByName)
+		carsGroup.GET("/name-available", h.CheckNameAvailable)
 		carsGroup.GET("/brand/:brand", h.GetCarsByBrand)
+		carsGroup.GET("/brand/:brand/count", h.CountCarsByBrand)
+		carsGroup.GET("/brand/:brand/export.csv", h.ExportCarsByBrandCSV)
+		carsGroup.GET("/color/:color", h.GetCarsByColor)
+		carsGroup.GET("/created-on/:date", h.GetCarsCreatedOn)
+		carsGroup.GET("/changes", h.GetCarChanges)
 		carsGroup.GET("/price-range", h.GetCarsByPriceRange)
+		carsGroup.GET("/year-range", h.GetCarsByYearRange)
 		carsGroup.POST("", h.CreateCar)
+		carsGroup.POST("/bulk", h.CreateCars)
+		carsGroup.POST("/validate-field", h.ValidateField)
+		carsGroup.POST("/merge", h.MergeCars)
+		carsGroup.POST("/adjust-price/preview", h.PreviewPriceAdjustment)
 		carsGroup.PUT("/:id", h.UpdateCar)
+		carsGroup.PUT("/by-name/:name", h.UpsertCarByName)
+		carsGroup.PATCH("/:id", h.PatchCar)
+		carsGroup.PATCH("/:id/price", h.UpdatePrice)
+		carsGroup.PATCH("/brand/:brand/price", h.UpdateBrandPrices)
+		carsGroup.DELETE("", h.DeleteCars)
 		carsGroup.DELETE("/:id", h.DeleteCar)
+		carsGroup.DELETE("/:id/scheduled-deletion", h.CancelCarDeletion)
 	}
 }
 
@@ -44,35 +90,149 @@ func (h *CarHandler) RegisterRoutes(router *gin.RouterGroup) {
 // @Accept  json
 // @Produce  json
 // @Param car body model.CarRequest true "Car object that needs to be added"
+// @Param Idempotency-Key header string false "Key making retries of this request, by the same client within 24 hours, replay the original response"
 // @Success 201 {object} model.CarResponse
+// @Header 201 {string} Location "Path of the created car"
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /cars [post]
 func (h *CarHandler) CreateCar(c *gin.Context) {
 	var req model.CarRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := h.bindJSON(c, &req); err != nil {
 		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
 		return
 	}
 
-	car, err := h.carService.CreateCar(c.Request.Context(), &req)
+	idempotencyKey := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		handleError(c, http.StatusBadRequest, "Invalid idempotency key", fmt.Errorf("key must not exceed %d characters", maxIdempotencyKeyLength))
+		return
+	}
+
+	key := service.IdempotencyKey{Client: idempotencyClient(c), Key: idempotencyKey}
+	car, _, err := h.carService.CreateCar(c.Request.Context(), &req, key)
 	if err != nil {
 		handleError(c, http.StatusInternalServerError, "Failed to create car", err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, car)
+	c.Header("Location", h.carLocation(car.ID))
+	respond(c, http.StatusCreated, car)
+}
+
+// idempotencyClient identifies the client an idempotency key belongs to: the
+// authenticated subject when there is one, its IP address otherwise
+func idempotencyClient(c *gin.Context) string {
+	if actor := c.GetString(actorContextKey); actor != "" {
+		return "actor:" + actor
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// CreateCars handles POST /api/v1/cars/bulk
+// @Summary Create several cars
+// @Description Create several cars at once. Either all cars are created or none are.
+// @Description With dryRun=true nothing is created; every invalid car is reported instead, with a 200 status whatever the outcome.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param cars body []model.CarRequest true "Cars that need to be added"
+// @Param dryRun query bool false "Only validate the cars"
+// @Success 200 {object} model.BatchValidationResponse
+// @Success 201 {array} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/bulk [post]
+func (h *CarHandler) CreateCars(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid dryRun", err)
+		return
+	}
+
+	var reqs []*model.CarRequest
+	if err := h.bindJSON(c, &reqs); err != nil {
+		// A dry run reports invalid cars through the service's validation instead,
+		// provided the payload was fully decoded
+		var unknownErr *unknownFieldError
+		if _, invalid := validationFields(err); !dryRun || !invalid || errors.As(err, &unknownErr) {
+			handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+			return
+		}
+	}
+
+	if len(reqs) == 0 || len(reqs) > service.MaxBatchSize {
+		handleError(c, http.StatusBadRequest, fmt.Sprintf("Between 1 and %d cars are required", service.MaxBatchSize), nil)
+		return
+	}
+
+	if dryRun {
+		result, err := h.carService.ValidateCars(c.Request.Context(), reqs)
+		if err != nil {
+			handleError(c, http.StatusInternalServerError, "Failed to validate cars", err)
+			return
+		}
+
+		respond(c, http.StatusOK, result)
+		return
+	}
+
+	cars, err := h.carService.CreateCars(c.Request.Context(), reqs)
+	if err != nil {
+		var itemErr *service.BatchItemError
+		if errors.As(err, &itemErr) {
+			handleError(c, http.StatusBadRequest, fmt.Sprintf("Invalid car at index %d", itemErr.Index), itemErr.Err)
+		} else {
+			handleError(c, http.StatusInternalServerError, "Failed to create cars", err)
+		}
+		return
+	}
+
+	respond(c, http.StatusCreated, cars)
+}
+
+// ValidateField handles POST /api/v1/cars/validate-field
+// @Summary Validate a single car field
+// @Description Check whether a single field value passes validation, without submitting a full car
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param field body model.FieldValidationRequest true "Field name and value to validate"
+// @Success 200 {object} model.FieldValidationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/validate-field [post]
+func (h *CarHandler) ValidateField(c *gin.Context) {
+	var req model.FieldValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	result, err := h.carService.ValidateField(c.Request.Context(), &req)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to validate field", err)
+		return
+	}
+
+	respond(c, http.StatusOK, result)
 }
 
 // GetCarByID handles GET /api/v1/cars/:id
 // @Summary Get a car by ID
-// @Description Get a car by its ID
+// @Description Get a car by its ID. With includeDeleted=true, which requires an admin token, soft-deleted cars are returned too, with their deleted_at.
 // @Tags cars
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Car ID"
+// @Param includeDeleted query bool false "Also return the car when it was soft deleted"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} model.CarResponse
+// @Success 304 "Not modified"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /cars/{id} [get]
@@ -83,26 +243,138 @@ func (h *CarHandler) GetCarByID(c *gin.Context) {
 		return
 	}
 
+	if includesDeleted(c) {
+		car, err := h.carService.GetCarByIDIncludingDeleted(c.Request.Context(), id)
+		if err != nil {
+			handleError(c, http.StatusInternalServerError, "Failed to get car", err)
+			return
+		}
+
+		respond(c, http.StatusOK, car)
+		return
+	}
+
 	car, err := h.carService.GetCarByID(c.Request.Context(), id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			handleError(c, http.StatusNotFound, "Car not found", err)
-		} else {
-			handleError(c, http.StatusInternalServerError, "Failed to get car", err)
+		handleError(c, http.StatusInternalServerError, "Failed to get car", err)
+		return
+	}
+
+	c.Header("ETag", car.ETag)
+	if etagMatches(c.GetHeader("If-None-Match"), car.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// HeadCarByID handles HEAD /api/v1/cars/:id
+// @Summary Check a car
+// @Description Check whether a car exists and get its ETag without the body
+// @Tags cars
+// @Param id path int true "Car ID"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 "Car exists"
+// @Success 304 "Not modified"
+// @Failure 400 "Invalid car ID"
+// @Failure 404 "Car not found"
+// @Failure 500 "Internal error"
+// @Router /cars/{id} [head]
+func (h *CarHandler) HeadCarByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	car, err := h.carService.GetCarByID(c.Request.Context(), id)
+	if err != nil {
+		status := statusForError(err)
+		if status == http.StatusInternalServerError {
+			logger.WithRequestID(c.Request.Context()).Errorf("Error: Failed to get car, Details: %v", err)
+		}
+		c.AbortWithStatus(status)
+		return
+	}
+
+	c.Header("ETag", car.ETag)
+	if etagMatches(c.GetHeader("If-None-Match"), car.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// etagMatches reports whether an If-None-Match or If-Match header value matches etag.
+// The header may list several tags or be "*"; weak tags compare by their opaque part.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetCarsByIDs handles GET /api/v1/cars/batch
+// @Summary Get several cars by ID
+// @Description Get the cars with the given IDs in the requested order. IDs that do not match a car are listed in missing.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param ids query string true "Comma-separated car IDs"
+// @Success 200 {object} model.CarBatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/batch [get]
+func (h *CarHandler) GetCarsByIDs(c *gin.Context) {
+	idsParam := c.Query("ids")
+	if idsParam == "" {
+		handleError(c, http.StatusBadRequest, "Car IDs are required", nil)
+		return
+	}
+
+	parts := strings.Split(idsParam, ",")
+	if len(parts) > service.MaxBatchSize {
+		handleError(c, http.StatusBadRequest, fmt.Sprintf("At most %d car IDs are allowed", service.MaxBatchSize), nil)
+		return
+	}
+
+	ids := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			handleError(c, http.StatusBadRequest, fmt.Sprintf("Invalid car ID %q", part), err)
+			return
 		}
+		ids = append(ids, id)
+	}
+
+	result, err := h.carService.GetCarsByIDs(c.Request.Context(), ids)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get cars", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, car)
+	respond(c, http.StatusOK, result)
 }
 
 // GetCarByName handles GET /api/v1/cars/name/:name
 // @Summary Get a car by name
-// @Description Get a car by its name
+// @Description Get a car by its name. When names are only unique per brand, the brand is required.
 // @Tags cars
 // @Accept  json
 // @Produce  json
 // @Param name path string true "Car Name"
+// @Param brand query string false "Car Brand"
 // @Success 200 {object} model.CarResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -115,17 +387,41 @@ func (h *CarHandler) GetCarByName(c *gin.Context) {
 		return
 	}
 
-	car, err := h.carService.GetCarByName(c.Request.Context(), name)
+	car, err := h.carService.GetCarByName(c.Request.Context(), name, c.Query("brand"))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			handleError(c, http.StatusNotFound, "Car not found", err)
-		} else {
-			handleError(c, http.StatusInternalServerError, "Failed to get car", err)
-		}
+		handleError(c, http.StatusInternalServerError, "Failed to get car", err)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// CheckNameAvailable handles GET /api/v1/cars/name-available
+// @Summary Check whether a car name is available
+// @Description Report whether a new car could be created with the given name; names of deleted cars can be reused
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param name query string true "Car Name"
+// @Param brand query string false "Car Brand, required when names are unique per brand"
+// @Success 200 {object} model.NameAvailabilityResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/name-available [get]
+func (h *CarHandler) CheckNameAvailable(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		handleError(c, http.StatusBadRequest, "Car name is required", nil)
+		return
+	}
+
+	availability, err := h.carService.CheckNameAvailable(c.Request.Context(), name, c.Query("brand"))
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to check name availability", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, car)
+	respond(c, http.StatusOK, availability)
 }
 
 // GetCarsByBrand handles GET /api/v1/cars/brand/:brand
@@ -152,159 +448,1291 @@ func (h *CarHandler) GetCarsByBrand(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, cars)
+	respond(c, http.StatusOK, cars)
 }
 
-// GetCarsByPriceRange handles GET /api/v1/cars/price-range
-// @Summary Get cars by price range
-// @Description Get all cars within a specified price range
+// GetCarsByColor handles GET /api/v1/cars/color/:color
+// @Summary Get cars by color
+// @Description Get all cars of a specific color, matched case-insensitively
 // @Tags cars
 // @Accept  json
 // @Produce  json
-// @Param startPrice query number true "Minimum price"
-// @Param finalPrice query number true "Maximum price"
+// @Param color path string true "Color"
 // @Success 200 {array} model.CarResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
-// @Router /cars/price-range [get]
-func (h *CarHandler) GetCarsByPriceRange(c *gin.Context) {
-	startPrice, err := strconv.ParseFloat(c.Query("startPrice"), 64)
-	if err != nil || startPrice < 0 {
-		handleError(c, http.StatusBadRequest, "Invalid start price", err)
+// @Router /cars/color/{color} [get]
+func (h *CarHandler) GetCarsByColor(c *gin.Context) {
+	color := c.Param("color")
+	if color == "" {
+		handleError(c, http.StatusBadRequest, "Color is required", nil)
 		return
 	}
 
-	finalPrice, err := strconv.ParseFloat(c.Query("finalPrice"), 64)
-	if err != nil || finalPrice < 0 || finalPrice < startPrice {
-		handleError(c, http.StatusBadRequest, "Invalid final price", err)
-		return
-	}
-
-	cars, err := h.carService.GetCarsByPriceRange(c.Request.Context(), startPrice, finalPrice)
+	cars, err := h.carService.GetCarsByColor(c.Request.Context(), color)
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, "Failed to get cars by price range", err)
+		handleError(c, http.StatusInternalServerError, "Failed to get cars by color", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, cars)
+	respond(c, http.StatusOK, cars)
 }
 
-// GetAllCars handles GET /api/v1/cars
-// @Summary Get all cars
-// @Description Get a list of all cars with pagination
+// GetCarsCreatedOn handles GET /api/v1/cars/created-on/:date
+// @Summary Get cars created on a day
+// @Description Get the cars created on a calendar day (UTC), oldest first
 // @Tags cars
 // @Accept  json
 // @Produce  json
-// @Param page query int false "Page number (default 1)"
-// @Param pageSize query int false "Number of items per page (default 10, max 100)"
+// @Param date path string true "Day, as YYYY-MM-DD"
 // @Success 200 {array} model.CarResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
-// @Router /cars [get]
-func (h *CarHandler) GetAllCars(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+// @Router /cars/created-on/{date} [get]
+func (h *CarHandler) GetCarsCreatedOn(c *gin.Context) {
+	date, err := time.Parse(time.DateOnly, c.Param("date"))
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD", err)
+		return
+	}
 
-	cars, err := h.carService.GetAllCars(c.Request.Context(), page, pageSize)
+	cars, err := h.carService.GetCarsCreatedOn(c.Request.Context(), date)
 	if err != nil {
-		handleError(c, http.StatusInternalServerError, "Failed to get cars", err)
+		handleError(c, http.StatusInternalServerError, "Failed to get cars by creation date", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, cars)
+	respond(c, http.StatusOK, cars)
 }
 
-// UpdateCar handles PUT /api/v1/cars/:id
-// @Summary Update an existing car
-// @Description Update an existing car with the input payload
+// GetCarChanges handles GET /api/v1/cars/changes
+// @Summary Get cars changed since a time
+// @Description Get the cars updated or deleted after since, in the order they changed, for clients syncing a local copy. Deleted cars are flagged so the client can remove them. Follow nextCursor until it is null, then pass that page's serverTime back as the next since. Purged cars are not reported, so clients must sync more often than the purge retention.
 // @Tags cars
 // @Accept  json
 // @Produce  json
-// @Param id path int true "Car ID"
-// @Param car body model.CarRequest true "Car object that needs to be updated"
-// @Success 200 {object} model.CarResponse
+// @Param since query string false "RFC 3339 time, e.g. 2024-01-01T00:00:00Z; required without cursor"
+// @Param cursor query string false "nextCursor of the previous page; replaces since"
+// @Param pageSize query int false "Number of changes per page (default 10, max 100, both configurable)"
+// @Success 200 {object} model.CarChangesResponse
 // @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
-// @Router /cars/{id} [put]
-func (h *CarHandler) UpdateCar(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil || id <= 0 {
-		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+// @Router /cars/changes [get]
+func (h *CarHandler) GetCarChanges(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+
+	var after model.ChangeCursor
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		var err error
+		after, err = model.ParseChangeCursor(cursor)
+		if err != nil {
+			handleError(c, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+	} else {
+		since, err := time.Parse(time.RFC3339, c.Query("since"))
+		if err != nil {
+			handleError(c, http.StatusBadRequest, "Invalid since, expected an RFC 3339 time", err)
+			return
+		}
+		after = model.ChangeCursorAt(since)
+	}
+
+	changes, err := h.carService.GetCarChanges(c.Request.Context(), after, pageSize)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get changed cars", err)
 		return
 	}
 
-	var req model.CarRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+	respond(c, http.StatusOK, changes)
+}
+
+// GetCarSchema handles GET /api/v1/cars/schema
+// @Summary Get the car payload schema
+// @Description Get the fields of a car payload with their types, whether they are required and their validation bounds, for generating forms
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.CarSchemaResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/schema [get]
+func (h *CarHandler) GetCarSchema(c *gin.Context) {
+	schema, err := h.carService.GetCarSchema(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get car schema", err)
 		return
 	}
 
-	car, err := h.carService.UpdateCar(c.Request.Context(), id, &req)
+	respond(c, http.StatusOK, schema)
+}
+
+// CountCars handles GET /api/v1/cars/count
+// @Summary Count cars
+// @Description Get the number of cars, excluding deleted ones
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.CarCountResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/count [get]
+func (h *CarHandler) CountCars(c *gin.Context) {
+	count, err := h.carService.CountCars(c.Request.Context())
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			handleError(c, http.StatusNotFound, "Car not found", err)
-		} else {
-			handleError(c, http.StatusInternalServerError, "Failed to update car", err)
-		}
+		handleError(c, http.StatusInternalServerError, "Failed to count cars", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, car)
+	respond(c, http.StatusOK, count)
 }
 
-// DeleteCar handles DELETE /api/v1/cars/:id
-// @Summary Delete a car
-// @Description Delete a car by its ID
+// CountCarsByBrand handles GET /api/v1/cars/brand/:brand/count
+// @Summary Count cars by brand
+// @Description Get the number of cars for a specific brand
 // @Tags cars
 // @Accept  json
 // @Produce  json
-// @Param id path int true "Car ID"
-// @Success 204 "No Content"
+// @Param brand path string true "Brand Name"
+// @Success 200 {object} model.BrandCountResponse
 // @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
-// @Router /cars/{id} [delete]
-func (h *CarHandler) DeleteCar(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil || id <= 0 {
-		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+// @Router /cars/brand/{brand}/count [get]
+func (h *CarHandler) CountCarsByBrand(c *gin.Context) {
+	brand := c.Param("brand")
+	if brand == "" {
+		handleError(c, http.StatusBadRequest, "Brand name is required", nil)
 		return
 	}
 
-	err = h.carService.DeleteCar(c.Request.Context(), id)
+	count, err := h.carService.CountCarsByBrand(c.Request.Context(), brand)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			handleError(c, http.StatusNotFound, "Car not found", err)
-		} else {
-			handleError(c, http.StatusInternalServerError, "Failed to delete car", err)
-		}
+		handleError(c, http.StatusInternalServerError, "Failed to count cars by brand", err)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	respond(c, http.StatusOK, count)
 }
 
-// ErrorResponse represents an error response
-// @Description Error response with message and optional error details
-type ErrorResponse struct {
-	Success bool   `json:"success" example:false`
-	Message string `json:"message" example:"An error occurred"`
-	Error   string `json:"error,omitempty" example:"error details"`
+// ExportCarsCSV handles GET /api/v1/cars/export.csv
+// @Summary Export all cars as CSV
+// @Description Download all active cars as a CSV file
+// @Tags cars
+// @Produce  text/csv
+// @Success 200 {file} file
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/export.csv [get]
+func (h *CarHandler) ExportCarsCSV(c *gin.Context) {
+	streamCarsCSV(c, "cars.csv", func(fn func(car *model.CarResponse) error) error {
+		return h.carService.StreamCars(c.Request.Context(), fn)
+	})
 }
 
-// handleError is a helper function to handle errors consistently
-func handleError(c *gin.Context, statusCode int, message string, err error) {
-	logger.Errorf("Error: %v, Details: %v", message, err)
-
-	errMsg := ""
-	if err != nil {
-		errMsg = err.Error()
+// ExportCarsByBrandCSV handles GET /api/v1/cars/brand/:brand/export.csv
+// @Summary Export a brand's cars as CSV
+// @Description Download all active cars of a brand, matched case-insensitively, as a CSV file. A brand without cars yields a header-only CSV.
+// @Tags cars
+// @Produce  text/csv
+// @Param brand path string true "Car Brand"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Router /cars/brand/{brand}/export.csv [get]
+func (h *CarHandler) ExportCarsByBrandCSV(c *gin.Context) {
+	brand := strings.TrimSpace(c.Param("brand"))
+	if brand == "" {
+		handleError(c, http.StatusBadRequest, "Brand name is required", nil)
+		return
 	}
 
-	c.JSON(statusCode, ErrorResponse{
-		Success: false,
-		Message: message,
-		Error:   errMsg,
+	streamCarsCSV(c, exportFilename(brand), func(fn func(car *model.CarResponse) error) error {
+		return h.carService.StreamCarsByBrand(c.Request.Context(), brand, fn)
 	})
 }
+
+// GetCarsByPriceRange handles GET /api/v1/cars/price-range
+// @Summary Get cars by price range
+// @Description Get all cars within a specified price range
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param startPrice query number true "Minimum price"
+// @Param finalPrice query number true "Maximum price"
+// @Success 200 {array} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/price-range [get]
+func (h *CarHandler) GetCarsByPriceRange(c *gin.Context) {
+	startPrice, err := strconv.ParseFloat(c.Query("startPrice"), 64)
+	if err != nil || startPrice < 0 {
+		handleError(c, http.StatusBadRequest, "Invalid start price", err)
+		return
+	}
+
+	finalPrice, err := strconv.ParseFloat(c.Query("finalPrice"), 64)
+	if err != nil || finalPrice < 0 || finalPrice < startPrice {
+		handleError(c, http.StatusBadRequest, "Invalid final price", err)
+		return
+	}
+
+	cars, err := h.carService.GetCarsByPriceRange(c.Request.Context(), startPrice, finalPrice)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get cars by price range", err)
+		return
+	}
+
+	respond(c, http.StatusOK, cars)
+}
+
+// GetCarsByYearRange handles GET /api/v1/cars/year-range
+// @Summary Get cars by year range
+// @Description Get all cars whose model year is within a specified range
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param startYear query int true "Minimum year"
+// @Param finalYear query int true "Maximum year"
+// @Success 200 {array} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/year-range [get]
+func (h *CarHandler) GetCarsByYearRange(c *gin.Context) {
+	startYear, err := strconv.Atoi(c.Query("startYear"))
+	if err != nil || startYear < service.MinYear || startYear > service.MaxYear {
+		handleError(c, http.StatusBadRequest, "Invalid start year", err)
+		return
+	}
+
+	finalYear, err := strconv.Atoi(c.Query("finalYear"))
+	if err != nil || finalYear < startYear || finalYear > service.MaxYear {
+		handleError(c, http.StatusBadRequest, "Invalid final year", err)
+		return
+	}
+
+	cars, err := h.carService.GetCarsByYearRange(c.Request.Context(), startYear, finalYear)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get cars by year range", err)
+		return
+	}
+
+	respond(c, http.StatusOK, cars)
+}
+
+// GetAllCars handles GET /api/v1/cars
+// @Summary Get all cars
+// @Description Get a list of all cars with pagination, optionally limited to cars created or updated within inclusive bounds, and to cars priced at or above minPrice and at or below maxPrice.
+// @Description With a cursor, cars are paginated by ID instead of page number and returned as a model.CarPage with the cursor of the next page, which stays fast however deep the page is.
+// @Description With Accept: application/hal+json, the page is returned in HAL form with the total and self, first, last, prev and next links.
+// @Tags cars
+// @Accept  json
+// @Produce  json,application/hal+json
+// @Param createdAfter query string false "RFC3339 time; only cars created at or after it"
+// @Param createdBefore query string false "RFC3339 time; only cars created at or before it"
+// @Param updatedAfter query string false "RFC3339 time; only cars updated at or after it"
+// @Param updatedBefore query string false "RFC3339 time; only cars updated at or before it"
+// @Param minPrice query number false "Only cars with a manufacturing value at or above it"
+// @Param maxPrice query number false "Only cars with a manufacturing value at or below it"
+// @Param cursor query string false "Return cars after this cursor; empty for the first page. Cannot be combined with page, time or price bounds"
+// @Param page query int false "Page number (default 1)"
+// @Param pageSize query int false "Number of items per page (default 10, max 100, both configurable)"
+// @Success 200 {array} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars [get]
+func (h *CarHandler) GetAllCars(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+
+	filter := model.CarFilter{Page: page, PageSize: pageSize}
+	if !bindTimeBounds(c, &filter) || !bindPriceBounds(c, &filter) {
+		return
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		h.getCarsByCursor(c, cursor, filter)
+		return
+	}
+
+	// Time and price bounds go through the filter query, which pages in the same order
+	if filter.HasTimeBounds() || filter.HasPriceBounds() {
+		cars, err := h.carService.FilterCars(c.Request.Context(), filter)
+		if err != nil {
+			handleError(c, http.StatusInternalServerError, "Failed to get cars", err)
+			return
+		}
+
+		respond(c, http.StatusOK, cars)
+		return
+	}
+
+	// Hypermedia clients get the page wrapped with links to its neighbours
+	if wantsHAL(c) {
+		carsPage, err := h.carService.GetCarsPage(c.Request.Context(), page, pageSize)
+		if err != nil {
+			handleError(c, http.StatusInternalServerError, "Failed to get cars", err)
+			return
+		}
+
+		c.Header("Content-Type", halContentType)
+		c.JSON(http.StatusOK, newHALCarPage(h.carsPath, carsPage))
+		return
+	}
+
+	cars, err := h.carService.GetAllCars(c.Request.Context(), page, pageSize)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, cars)
+}
+
+// getCarsByCursor serves GET /api/v1/cars with a cursor, paginating by ID
+func (h *CarHandler) getCarsByCursor(c *gin.Context, cursor string, filter model.CarFilter) {
+	if _, ok := c.GetQuery("page"); ok || filter.HasTimeBounds() || filter.HasPriceBounds() {
+		handleError(c, http.StatusBadRequest, "Invalid query", errors.New("cursor cannot be combined with page, time or price bounds"))
+		return
+	}
+
+	var afterID int64
+	if cursor != "" {
+		var err error
+		afterID, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || afterID < 0 {
+			handleError(c, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+	}
+
+	page, err := h.carService.GetCarsAfter(c.Request.Context(), afterID, filter.PageSize)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, page)
+}
+
+// GetRecentCars handles GET /api/v1/cars/recent
+// @Summary Get recently added cars
+// @Description Get the most recently created cars, newest first
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "Number of cars (default 10, max 50)"
+// @Success 200 {array} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/recent [get]
+func (h *CarHandler) GetRecentCars(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+
+	cars, err := h.carService.GetRecentCars(c.Request.Context(), limit)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get recent cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, cars)
+}
+
+// GetRelatedCars handles GET /api/v1/cars/:id/related
+// @Summary Get a car and related cars
+// @Description Get a car along with other cars of the same brand, those closest in price first
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Car ID"
+// @Param limit query int false "Number of related cars (default 5, max 50)"
+// @Success 200 {object} model.RelatedCarsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/{id}/related [get]
+func (h *CarHandler) GetRelatedCars(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+
+	related, err := h.carService.GetRelatedCars(c.Request.Context(), id, limit)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get related cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, related)
+}
+
+// GetCarExtremes handles GET /api/v1/cars/extremes
+// @Summary Get the cheapest and most expensive cars
+// @Description Get the active cars with the lowest and highest manufacturing value; both are null when there are no cars
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.CarExtremesResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/extremes [get]
+func (h *CarHandler) GetCarExtremes(c *gin.Context) {
+	extremes, err := h.carService.GetCarExtremes(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get car extremes", err)
+		return
+	}
+
+	respond(c, http.StatusOK, extremes)
+}
+
+// GetDeletedCars handles GET /api/v1/cars/deleted
+// @Summary Get deleted cars
+// @Description Get a list of soft-deleted cars with pagination, most recently deleted first. Requires an admin token.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param page query int false "Page number (default 1)"
+// @Param pageSize query int false "Number of items per page (default 10, max 100, both configurable)"
+// @Success 200 {array} model.DeletedCarResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/deleted [get]
+func (h *CarHandler) GetDeletedCars(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+
+	cars, err := h.carService.GetDeletedCars(c.Request.Context(), page, pageSize)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get deleted cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, cars)
+}
+
+// FilterCars handles GET /api/v1/cars/filter
+// @Summary Filter cars
+// @Description Get cars matching all of the given criteria, with pagination. Omitted criteria are not applied.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param brand query string false "Brand"
+// @Param minPrice query number false "Minimum price"
+// @Param maxPrice query number false "Maximum price"
+// @Param minYear query int false "Minimum year"
+// @Param maxYear query int false "Maximum year"
+// @Param page query int false "Page number (default 1)"
+// @Param pageSize query int false "Number of items per page (default 10, max 100, both configurable)"
+// @Success 200 {array} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/filter [get]
+func (h *CarHandler) FilterCars(c *gin.Context) {
+	filter := model.CarFilter{Brand: c.Query("brand")}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.Query("pageSize"))
+
+	if !bindPriceBounds(c, &filter) {
+		return
+	}
+
+	var err error
+	if filter.MinYear, err = optionalIntQuery(c, "minYear"); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid minimum year", err)
+		return
+	}
+
+	if filter.MaxYear, err = optionalIntQuery(c, "maxYear"); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid maximum year", err)
+		return
+	}
+
+	cars, err := h.carService.FilterCars(c.Request.Context(), filter)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to filter cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, cars)
+}
+
+// SearchCars handles GET /api/v1/cars/search
+// @Summary Search cars
+// @Description Search cars whose name, brand or description contains the query, case-insensitively
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Search text"
+// @Param page query int false "Page number (default 1)"
+// @Param pageSize query int false "Number of items per page (default 10, max 100, both configurable)"
+// @Success 200 {array} model.CarResponse
+// @Header 200 {string} X-Results-Capped "true when matches were cut off by the search result cap"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/search [get]
+func (h *CarHandler) SearchCars(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		handleError(c, http.StatusBadRequest, "Search query is required", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+
+	cars, capped, err := h.carService.SearchCars(c.Request.Context(), query, page, pageSize)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to search cars", err)
+		return
+	}
+
+	if capped {
+		c.Header(resultsCappedHeader, "true")
+	}
+
+	respond(c, http.StatusOK, cars)
+}
+
+// GetBrandsByValue handles GET /api/v1/cars/stats/brands-by-value
+// @Summary Get brands by inventory value
+// @Description Get the brands with the count and summed manufacturing value of their cars, ordered by total value descending
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "Maximum number of brands (default all)"
+// @Success 200 {array} model.BrandValueResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/stats/brands-by-value [get]
+func (h *CarHandler) GetBrandsByValue(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		handleError(c, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+
+	brands, err := h.carService.GetBrandsByValue(c.Request.Context(), limit)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get brands by value", err)
+		return
+	}
+
+	respond(c, http.StatusOK, brands)
+}
+
+// GetStatsByBrand handles GET /api/v1/cars/stats/by-brand
+// @Summary Get price statistics by brand
+// @Description Get the car count and average, minimum and maximum manufacturing value per brand with pagination, ordered by count descending
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page number (default 1)"
+// @Param pageSize query int false "Number of items per page (default 10, max 100, both configurable)"
+// @Success 200 {array} model.BrandStatsResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/stats/by-brand [get]
+func (h *CarHandler) GetStatsByBrand(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+
+	stats, err := h.carService.GetStatsByBrand(c.Request.Context(), page, pageSize)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get stats by brand", err)
+		return
+	}
+
+	respond(c, http.StatusOK, stats)
+}
+
+// ListBrands handles GET /api/v1/cars/brands
+// @Summary List brands
+// @Description Get the distinct brands of all cars in alphabetical order
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} string
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/brands [get]
+func (h *CarHandler) ListBrands(c *gin.Context) {
+	brands, err := h.carService.ListBrands(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list brands", err)
+		return
+	}
+
+	respond(c, http.StatusOK, brands)
+}
+
+// GetRandomFeaturedCar handles GET /api/v1/cars/featured/random
+// @Summary Get a random featured car
+// @Description Get one featured car picked at random, where cars with a higher weight are picked more often
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.CarResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/featured/random [get]
+func (h *CarHandler) GetRandomFeaturedCar(c *gin.Context) {
+	car, err := h.carService.GetRandomFeaturedCar(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get random featured car", err)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// GetCatalogSummary handles GET /api/v1/cars/summary
+// @Summary Get a catalog summary
+// @Description Get total cars, total brands, total inventory value and the oldest/newest creation times
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.CatalogSummaryResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/summary [get]
+func (h *CarHandler) GetCatalogSummary(c *gin.Context) {
+	summary, err := h.carService.GetCatalogSummary(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get catalog summary", err)
+		return
+	}
+
+	respond(c, http.StatusOK, summary)
+}
+
+// UpdateCar handles PUT /api/v1/cars/:id
+// @Summary Update an existing car
+// @Description Update an existing car with the input payload. The payload must carry the car's current version; a stale version is rejected with 409.
+// @Description With an If-Match header, the update only goes ahead when the car's ETag matches (or the car exists, for "*"), and the version may then be omitted.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Car ID"
+// @Param car body model.CarRequest true "Car object that needs to be updated"
+// @Param If-Match header string false "ETag the update is based on, or * to only require that the car exists"
+// @Success 200 {object} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/{id} [put]
+func (h *CarHandler) UpdateCar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+		return
+	}
+
+	var req model.CarRequest
+	if err := h.bindJSON(c, &req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !h.checkIfMatch(c, id, ifMatch, &req) {
+		return
+	}
+
+	car, err := h.carService.UpdateCar(c.Request.Context(), id, &req)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to update car", err)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// mergePatchContentType is the media type of JSON merge patch (RFC 7386) payloads
+const mergePatchContentType = "application/merge-patch+json"
+
+// PatchCar handles PATCH /api/v1/cars/:id
+// @Summary Partially update a car
+// @Description Apply a JSON merge patch (RFC 7386) to a car: absent keys are left unchanged and a null clears an optional field such as the description.
+// @Description The update is based on the version in the patch, else the version matched by an If-Match header, else the car's current version.
+// @Tags cars
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param id path int true "Car ID"
+// @Param patch body object true "Merge patch of the car's fields"
+// @Param If-Match header string false "ETag the update is based on, or * to only require that the car exists"
+// @Success 200 {object} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/{id} [patch]
+func (h *CarHandler) PatchCar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+		return
+	}
+
+	if c.ContentType() != mergePatchContentType {
+		handleError(c, http.StatusUnsupportedMediaType, "Unsupported content type", fmt.Errorf("content type must be %s", mergePatchContentType))
+		return
+	}
+
+	if c.Request.Body == nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", errors.New("request body is empty"))
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	var base model.CarRequest
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !h.checkIfMatch(c, id, ifMatch, &base) {
+		return
+	}
+
+	car, err := h.carService.PatchCar(c.Request.Context(), id, patch, base.Version)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to patch car", err)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// UpdatePrice handles PATCH /api/v1/cars/:id/price
+// @Summary Update a car's price
+// @Description Change only the manufacturing value of a car
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Car ID"
+// @Param price body model.PriceUpdateRequest true "New manufacturing value"
+// @Success 200 {object} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/{id}/price [patch]
+func (h *CarHandler) UpdatePrice(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+		return
+	}
+
+	var req model.PriceUpdateRequest
+	if err := h.bindJSON(c, &req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	car, err := h.carService.UpdatePrice(c.Request.Context(), id, req.ManufacturingValue)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to update car price", err)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// checkIfMatch checks an If-Match header against the car's current ETag, writing
+// the error response and returning false when the update must not go ahead. The
+// matched version is used when the request does not carry one, so the car
+// changing between this check and the update still ends in a conflict.
+func (h *CarHandler) checkIfMatch(c *gin.Context, id int64, ifMatch string, req *model.CarRequest) bool {
+	current, err := h.carService.GetCarByID(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get car", err)
+		return false
+	}
+
+	if !etagMatches(ifMatch, current.ETag) {
+		handleError(c, http.StatusPreconditionFailed, "Car has been modified", nil)
+		return false
+	}
+
+	if req.Version == 0 {
+		req.Version = current.Version
+	}
+
+	return true
+}
+
+// UpsertCarByName handles PUT /api/v1/cars/by-name/:name
+// @Summary Create or update a car by name
+// @Description Update the car with the given name, or create it when there is none. The version is optional; without it the update applies to the current version.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param name path string true "Car Name"
+// @Param car body model.CarRequest true "Car object; its name must match the path"
+// @Success 200 {object} model.CarResponse
+// @Success 201 {object} model.CarResponse
+// @Header 201 {string} Location "Path of the created car"
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/by-name/{name} [put]
+func (h *CarHandler) UpsertCarByName(c *gin.Context) {
+	name := c.Param("name")
+	if strings.TrimSpace(name) == "" {
+		handleError(c, http.StatusBadRequest, "Car name is required", nil)
+		return
+	}
+
+	var req model.CarRequest
+	if err := h.bindJSON(c, &req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	car, created, err := h.carService.UpsertCarByName(c.Request.Context(), name, &req)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to upsert car", err)
+		return
+	}
+
+	if created {
+		c.Header("Location", h.carLocation(car.ID))
+		respond(c, http.StatusCreated, car)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// DeleteCar handles DELETE /api/v1/cars/:id
+// @Summary Delete a car
+// @Description Delete a car by its ID, or schedule its deletion when an "at" time is given
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Car ID"
+// @Param at query string false "RFC3339 time in the future at which to delete the car"
+// @Success 202 {object} model.CarResponse
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/{id} [delete]
+func (h *CarHandler) DeleteCar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+		return
+	}
+
+	if atParam := c.Query("at"); atParam != "" {
+		at, err := time.Parse(time.RFC3339, atParam)
+		if err != nil {
+			handleError(c, http.StatusBadRequest, "Invalid deletion time, expected RFC3339", err)
+			return
+		}
+
+		if !at.After(time.Now()) {
+			handleError(c, http.StatusBadRequest, "Deletion time must be in the future", nil)
+			return
+		}
+
+		car, err := h.carService.ScheduleCarDeletion(c.Request.Context(), id, at)
+		if err != nil {
+			handleError(c, http.StatusInternalServerError, "Failed to schedule car deletion", err)
+			return
+		}
+
+		respond(c, http.StatusAccepted, car)
+		return
+	}
+
+	err = h.carService.DeleteCar(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to delete car", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteCars handles DELETE /api/v1/cars
+// @Summary Delete several cars
+// @Description Delete several cars by ID at once. IDs that do not match an existing car are listed in notFound.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param ids body model.DeleteCarsRequest true "IDs of the cars to delete"
+// @Success 200 {object} model.DeleteCarsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars [delete]
+func (h *CarHandler) DeleteCars(c *gin.Context) {
+	var req model.DeleteCarsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if len(req.IDs) == 0 || len(req.IDs) > service.MaxBatchSize {
+		handleError(c, http.StatusBadRequest, fmt.Sprintf("Between 1 and %d car IDs are required", service.MaxBatchSize), nil)
+		return
+	}
+
+	result, err := h.carService.DeleteCars(c.Request.Context(), req.IDs)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to delete cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, result)
+}
+
+// MergeCars handles POST /api/v1/cars/merge
+// @Summary Merge duplicate cars
+// @Description Merge a duplicate car into a primary one. The duplicate is deleted and its description is copied over when the primary has none.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param merge body model.MergeCarsRequest true "Primary and duplicate car IDs"
+// @Success 200 {object} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/merge [post]
+func (h *CarHandler) MergeCars(c *gin.Context) {
+	var req model.MergeCarsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if req.PrimaryID == req.DuplicateID {
+		handleError(c, http.StatusBadRequest, "A car cannot be merged into itself", nil)
+		return
+	}
+
+	car, err := h.carService.MergeCars(c.Request.Context(), &req)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to merge cars", err)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// PreviewPriceAdjustment handles POST /api/v1/cars/adjust-price/preview
+// @Summary Preview a price adjustment
+// @Description Show the current and proposed prices of a brand's cars for a percentage price change, without saving anything. Cars whose proposed price would reach the maximum are flagged.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param adjustment body model.PriceAdjustmentRequest true "Brand and percent change"
+// @Param page query int false "Page number (default 1)"
+// @Param pageSize query int false "Number of items per page (default 10, max 100, both configurable)"
+// @Success 200 {object} model.PriceAdjustmentPreviewResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/adjust-price/preview [post]
+func (h *CarHandler) PreviewPriceAdjustment(c *gin.Context) {
+	var req model.PriceAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+
+	preview, err := h.carService.PreviewPriceAdjustment(c.Request.Context(), &req, page, pageSize)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to preview price adjustment", err)
+		return
+	}
+
+	respond(c, http.StatusOK, preview)
+}
+
+// UpdateBrandPrices handles PATCH /api/v1/cars/brand/:brand/price
+// @Summary Change the prices of a brand
+// @Description Apply a percentage change to the manufacturing value of every active car of a brand in one transaction. The change is rejected as a whole when any resulting price would fall outside the allowed range.
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param brand path string true "Car brand"
+// @Param change body model.BrandPriceUpdateRequest true "Percent change, e.g. 5 for a 5% increase"
+// @Success 200 {object} model.BrandPriceUpdateResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/brand/{brand}/price [patch]
+func (h *CarHandler) UpdateBrandPrices(c *gin.Context) {
+	var req model.BrandPriceUpdateRequest
+	if err := h.bindJSON(c, &req); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	result, err := h.carService.UpdateBrandPrices(c.Request.Context(), c.Param("brand"), req.PercentChange)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to update brand prices", err)
+		return
+	}
+
+	respond(c, http.StatusOK, result)
+}
+
+// CancelCarDeletion handles DELETE /api/v1/cars/:id/scheduled-deletion
+// @Summary Cancel a scheduled car deletion
+// @Description Cancel a pending scheduled deletion of a car
+// @Tags cars
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Car ID"
+// @Success 200 {object} model.CarResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cars/{id}/scheduled-deletion [delete]
+func (h *CarHandler) CancelCarDeletion(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, "Invalid car ID", err)
+		return
+	}
+
+	car, err := h.carService.CancelCarDeletion(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to cancel car deletion", err)
+		return
+	}
+
+	respond(c, http.StatusOK, car)
+}
+
+// ErrorResponse represents an error response
+// @Description Error response with message and optional error details
+type ErrorResponse struct {
+	Success bool   `json:"success" example:"false"`
+	Message string `json:"message" example:"An error occurred"`
+	Error   string `json:"error,omitempty" example:"error details"`
+	// Fields maps each invalid field to what is wrong with it
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// bindJSON binds a car payload like ShouldBindJSON. In strict mode, fields the
+// payload type does not declare are rejected instead of silently ignored.
+func (h *CarHandler) bindJSON(c *gin.Context, obj interface{}) error {
+	if !h.strictJSON {
+		return c.ShouldBindJSON(obj)
+	}
+
+	if c.Request.Body == nil {
+		return errors.New("request body is empty")
+	}
+
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return &unknownFieldError{Field: strings.Trim(field, `"`)}
+		}
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// includesDeleted reports whether the request asks for soft-deleted cars too
+func includesDeleted(c *gin.Context) bool {
+	includeDeleted, _ := strconv.ParseBool(c.Query("includeDeleted"))
+	return includeDeleted
+}
+
+// carLocation returns the URL path of the car with the given ID, including any base path
+func (h *CarHandler) carLocation(id int64) string {
+	return fmt.Sprintf("%s/%d", h.carsPath, id)
+}
+
+// optionalFloatQuery parses a float query parameter, returning nil when it is absent.
+// NaN and infinities are rejected.
+func optionalFloatQuery(c *gin.Context, key string) (*float64, error) {
+	raw, ok := c.GetQuery(key)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("%q is not a finite number", raw)
+	}
+
+	return &value, nil
+}
+
+// optionalIntQuery parses an integer query parameter, returning nil when it is absent
+func optionalIntQuery(c *gin.Context, key string) (*int, error) {
+	raw, ok := c.GetQuery(key)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// optionalTimeQuery parses an RFC3339 time query parameter, returning nil when it is absent
+func optionalTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	raw, ok := c.GetQuery(key)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// bindTimeBounds reads the creation and update time bounds into filter, writing
+// a 400 and returning false when one is malformed
+func bindTimeBounds(c *gin.Context, filter *model.CarFilter) bool {
+	bounds := []struct {
+		key    string
+		target **time.Time
+	}{
+		{"createdAfter", &filter.CreatedAfter},
+		{"createdBefore", &filter.CreatedBefore},
+		{"updatedAfter", &filter.UpdatedAfter},
+		{"updatedBefore", &filter.UpdatedBefore},
+	}
+
+	for _, bound := range bounds {
+		value, err := optionalTimeQuery(c, bound.key)
+		if err != nil {
+			handleError(c, http.StatusBadRequest, fmt.Sprintf("Invalid %s, expected RFC3339", bound.key), err)
+			return false
+		}
+		*bound.target = value
+	}
+
+	return true
+}
+
+// bindPriceBounds reads the optional minPrice and maxPrice query parameters into
+// filter, writing a 400 and returning false when one is not a number
+func bindPriceBounds(c *gin.Context, filter *model.CarFilter) bool {
+	var err error
+	if filter.MinPrice, err = optionalFloatQuery(c, "minPrice"); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid minimum price", err)
+		return false
+	}
+
+	if filter.MaxPrice, err = optionalFloatQuery(c, "maxPrice"); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid maximum price", err)
+		return false
+	}
+
+	return true
+}
+
+// handleError is a helper function to handle errors consistently.
+// A 500 status is refined to a more specific one when err wraps a known domain error.
+func handleError(c *gin.Context, statusCode int, message string, err error) {
+	if statusCode == http.StatusInternalServerError {
+		statusCode = statusForError(err)
+	}
+
+	// A body cut off by BodySizeLimit fails to bind, whatever status the handler chose
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		statusCode = http.StatusRequestEntityTooLarge
+		message = "Request body too large"
+	}
+
+	// Payloads failing their binding rules get per-field details instead of the raw validator error
+	if fields, ok := validationFields(err); ok {
+		logger.WithRequestID(c.Request.Context()).Debugf("Validation failed: %v", err)
+		respond(c, http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: validationFailedMessage,
+			Fields:  fields,
+		})
+		return
+	}
+
+	// The driver may report an abandoned query with its own error, so fall back to
+	// the request context to tell cancellations apart from real failures
+	if statusCode == http.StatusInternalServerError && c.Request.Context().Err() != nil {
+		statusCode = statusForError(c.Request.Context().Err())
+	}
+
+	if statusCode == statusClientClosedRequest {
+		// Nobody is left to read the response
+		logger.WithRequestID(c.Request.Context()).Debugf("Request canceled: %v, Details: %v", message, err)
+		c.AbortWithStatus(statusCode)
+		return
+	}
+
+	logger.WithRequestID(c.Request.Context()).Errorf("Error: %v, Details: %v", message, err)
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	respond(c, statusCode, ErrorResponse{
+		Success: false,
+		Message: message,
+		Error:   errMsg,
+	})
+}
+
+const (
+	// idempotencyKeyHeader carries a client-chosen key making car creation safe to retry
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	// maxIdempotencyKeyLength matches the size of the idempotency_keys.key column
+	maxIdempotencyKeyLength = 255
+)
+
+// resultsCappedHeader is set on search responses whose matches were cut off by the result cap
+const resultsCappedHeader = "X-Results-Capped"
+
+// statusClientClosedRequest is the non-standard status (popularised by nginx) used
+// when the client went away before the response was written
+const statusClientClosedRequest = 499
+
+// statusForError maps domain and context errors to HTTP status codes
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, errs.ErrCarNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrDuplicateCar), errors.Is(err, errs.ErrVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrInvalidInput), errors.Is(err, errs.ErrUnknownField):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
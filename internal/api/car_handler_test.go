@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/username/go-car-service/internal/errs"
+)
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "not found", err: errs.ErrCarNotFound, want: http.StatusNotFound},
+		{name: "wrapped not found", err: fmt.Errorf("failed to get car: %w", errs.ErrCarNotFound), want: http.StatusNotFound},
+		{name: "duplicate", err: errs.ErrDuplicateCar, want: http.StatusConflict},
+		{name: "version conflict", err: errs.ErrVersionConflict, want: http.StatusConflict},
+		{name: "invalid input", err: fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput), want: http.StatusBadRequest},
+		{name: "unknown field", err: errs.ErrUnknownField, want: http.StatusBadRequest},
+		{name: "canceled", err: context.Canceled, want: statusClientClosedRequest},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: http.StatusServiceUnavailable},
+		{name: "unexpected", err: errors.New("connection refused"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusForError(tt.err); got != tt.want {
+				t.Errorf("statusForError(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		err        error
+		wantStatus int
+	}{
+		{name: "not found", status: http.StatusInternalServerError, err: errs.ErrCarNotFound, wantStatus: http.StatusNotFound},
+		{name: "duplicate", status: http.StatusInternalServerError, err: errs.ErrDuplicateCar, wantStatus: http.StatusConflict},
+		{name: "invalid input", status: http.StatusInternalServerError, err: errs.ErrInvalidInput, wantStatus: http.StatusBadRequest},
+		{name: "unexpected", status: http.StatusInternalServerError, err: errors.New("disk full"), wantStatus: http.StatusInternalServerError},
+		{name: "explicit status kept", status: http.StatusBadRequest, err: errs.ErrCarNotFound, wantStatus: http.StatusBadRequest},
+		{name: "oversized body", status: http.StatusBadRequest, err: &http.MaxBytesError{Limit: 1}, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cars/1", nil)
+
+			handleError(c, tt.status, "Failed", tt.err)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if body.Success || body.Error != tt.err.Error() {
+				t.Errorf("body = %+v, want success false and error %q", body, tt.err)
+			}
+		})
+	}
+}
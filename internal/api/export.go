@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// csvFlushEvery is the number of rows written between flushes to the client
+const csvFlushEvery = 100
+
+// csvHeader lists the columns of a car CSV export
+var csvHeader = []string{"id", "name", "brand", "manufacturing_value", "year", "description", "created_at"}
+
+// unsafeFilenameChars matches runs of characters not kept in export filenames
+var unsafeFilenameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// streamCarsCSV writes the cars produced by stream to the response as a CSV
+// attachment named filename, flushing as it goes so the export is never held in
+// memory. The header row is always written, so an empty export is a valid CSV.
+// The response is only started with the first row, so a failing query still gets
+// a JSON error; a failure midway can only abort the response and is logged.
+func streamCarsCSV(c *gin.Context, filename string, stream func(fn func(car *model.CarResponse) error) error) {
+	writer := csv.NewWriter(c.Writer)
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		return writer.Write(csvHeader)
+	}
+
+	rows := 0
+	err := stream(func(car *model.CarResponse) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Write(carCSVRecord(car)); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%csvFlushEvery == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+		return writer.Error()
+	})
+
+	if err != nil && !started {
+		handleError(c, http.StatusInternalServerError, "Failed to export cars", err)
+		return
+	}
+
+	if err == nil && !started {
+		err = start()
+	}
+
+	writer.Flush()
+	if err == nil {
+		err = writer.Error()
+	}
+	if err != nil {
+		logger.WithRequestID(c.Request.Context()).Errorf("CSV export of %s aborted after %d rows: %v", filename, rows, err)
+		c.Abort()
+	}
+}
+
+// carCSVRecord converts a car to a CSV record in csvHeader order
+func carCSVRecord(car *model.CarResponse) []string {
+	year := ""
+	if car.Year != 0 {
+		year = strconv.Itoa(car.Year)
+	}
+
+	description := ""
+	if car.Description != nil {
+		description = *car.Description
+	}
+
+	return []string{
+		strconv.FormatInt(car.ID, 10),
+		car.Name,
+		car.Brand,
+		car.ManufacturingValue.String(),
+		year,
+		description,
+		car.CreatedAt,
+	}
+}
+
+// exportFilename builds a safe CSV filename from a label, e.g. "Alfa Romeo" -> "alfa-romeo-cars.csv"
+func exportFilename(label string) string {
+	slug := strings.Trim(unsafeFilenameChars.ReplaceAllString(strings.ToLower(label), "-"), "-")
+	if slug == "" {
+		return "cars.csv"
+	}
+	return slug + "-cars.csv"
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportFilename(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{label: "Honda", want: "honda-cars.csv"},
+		{label: "Alfa Romeo", want: "alfa-romeo-cars.csv"},
+		{label: `../"evil"`, want: "evil-cars.csv"},
+		{label: "***", want: "cars.csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			if got := exportFilename(tt.label); got != tt.want {
+				t.Errorf("exportFilename(%q) = %q, want %q", tt.label, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportCarsByBrandCSV(t *testing.T) {
+	engine := newTestRouter(t, nil)
+	for _, body := range []string{
+		`{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000, "year": 2020, "description": "One owner, low mileage"}`,
+		`{"name": "Accord", "brand": "Honda", "manufacturing_value": 30000.5}`,
+		`{"name": "Corolla", "brand": "Toyota", "manufacturing_value": 22000}`,
+	} {
+		mustCreateCar(t, engine, body)
+	}
+
+	tests := []struct {
+		name            string
+		brand           string
+		wantRows        int
+		wantDisposition string
+	}{
+		{name: "brand with cars", brand: "Honda", wantRows: 2, wantDisposition: `attachment; filename="honda-cars.csv"`},
+		{name: "case insensitive", brand: "toyota", wantRows: 1, wantDisposition: `attachment; filename="toyota-cars.csv"`},
+		{name: "unknown brand", brand: "Lada", wantRows: 0, wantDisposition: `attachment; filename="lada-cars.csv"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(engine, http.MethodGet, "/api/v1/cars/brand/"+tt.brand+"/export.csv", "", nil)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+			}
+			if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+				t.Errorf("Content-Type = %q, want text/csv", got)
+			}
+			if got := recorder.Header().Get("Content-Disposition"); got != tt.wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.wantDisposition)
+			}
+
+			records, err := csv.NewReader(recorder.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("failed to parse CSV: %v", err)
+			}
+			if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+				t.Fatalf("header = %v, want %v", records, csvHeader)
+			}
+			if got := len(records) - 1; got != tt.wantRows {
+				t.Errorf("got %d rows, want %d", got, tt.wantRows)
+			}
+			for _, record := range records[1:] {
+				if !strings.EqualFold(record[2], tt.brand) {
+					t.Errorf("row %v has brand %q, want %q", record, record[2], tt.brand)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/username/go-car-service/internal/model"
+)
+
+// halContentType is the media type of HAL responses
+const halContentType = "application/hal+json"
+
+// halLink is a HAL link object
+type halLink struct {
+	Href string `json:"href"`
+}
+
+// halCars is the embedded resource list of a HAL car page
+type halCars struct {
+	Cars []*model.CarResponse `json:"cars"`
+}
+
+// halCarPage is a page of cars in HAL form, linking to the neighbouring pages
+type halCarPage struct {
+	Links    map[string]halLink `json:"_links"`
+	Embedded halCars            `json:"_embedded"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"pageSize"`
+	Total    int64              `json:"total"`
+}
+
+// wantsHAL reports whether the client asked for a HAL response
+func wantsHAL(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), halContentType)
+}
+
+// newHALCarPage wraps a page of cars with self, first, last, prev and next links
+// to the list at path. Prev and next are left out on the first and last pages.
+func newHALCarPage(path string, page *model.CarListPage) *halCarPage {
+	lastPage := int((page.Total + int64(page.PageSize) - 1) / int64(page.PageSize))
+	lastPage = max(lastPage, 1)
+
+	link := func(n int) halLink {
+		return halLink{Href: fmt.Sprintf("%s?page=%d&pageSize=%d", path, n, page.PageSize)}
+	}
+
+	links := map[string]halLink{
+		"self":  link(page.Page),
+		"first": link(1),
+		"last":  link(lastPage),
+	}
+	if page.Page > 1 {
+		links["prev"] = link(min(page.Page-1, lastPage))
+	}
+	if page.Page < lastPage {
+		links["next"] = link(page.Page + 1)
+	}
+
+	return &halCarPage{
+		Links:    links,
+		Embedded: halCars{Cars: page.Cars},
+		Page:     page.Page,
+		PageSize: page.PageSize,
+		Total:    page.Total,
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/username/go-car-service/internal/repository"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// MigrationHealth reports the applied and expected schema versions, answering
+// 503 when the schema is behind the application or a migration failed midway
+func MigrationHealth(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := store.MigrationStatus(c.Request.Context())
+		if err != nil {
+			logger.WithRequestID(c.Request.Context()).Errorf("Failed to get migration status: %v", err)
+			respond(c, http.StatusServiceUnavailable, ErrorResponse{
+				Success: false,
+				Message: "Failed to get migration status",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if !status.UpToDate() {
+			respond(c, http.StatusServiceUnavailable, status)
+			return
+		}
+
+		respond(c, http.StatusOK, status)
+	}
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/username/go-car-service/pkg/logger"
+	"github.com/username/go-car-service/pkg/metrics"
+	"github.com/username/go-car-service/pkg/tracing"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// requestIDHeader is the header used to read and return the request ID
+	requestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength bounds incoming request IDs so they can't bloat the logs
+	maxRequestIDLength = 128
+
+	// rateLimitIdleTTL is how long a client's bucket is kept after its last request
+	rateLimitIdleTTL = 10 * time.Minute
+)
+
+// shuttingDown is set once the server starts draining, see BeginShutdown
+var shuttingDown atomic.Bool
+
+// BeginShutdown makes RejectDuringShutdown turn away new requests. Requests
+// already past the middleware are left to finish.
+func BeginShutdown() {
+	shuttingDown.Store(true)
+}
+
+// RejectDuringShutdown answers requests arriving after BeginShutdown with a 503
+// and a Retry-After header, so load balancers and clients retry on another instance.
+func RejectDuringShutdown(retryAfter time.Duration) gin.HandlerFunc {
+	retryAfterSeconds := strconv.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1))
+
+	return func(c *gin.Context) {
+		if shuttingDown.Load() {
+			c.Header("Retry-After", retryAfterSeconds)
+			c.Header("Connection", "close")
+			abortWithError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Success: false,
+				Message: "Server is shutting down",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequestID assigns every request an ID, reusing the incoming X-Request-ID header
+// when present. The ID is stored in the request context so service and repository
+// logs can include it, and it is echoed back in the response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
+		c.Header(requestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// RequestLogger logs every request through logger.LogRequest once the rest of
+// the handler chain has run, so the final status and latency are recorded.
+// Successful requests to excludePaths (e.g. probes) are not logged; failed ones still are.
+// Of the other successful requests, only a sampleRate fraction picked at random is
+// logged; a sampleRate of 1 or more logs them all.
+func RequestLogger(excludePaths []string, sampleRate float64) gin.HandlerFunc {
+	excluded := make(map[string]struct{}, len(excludePaths))
+	for _, path := range excludePaths {
+		excluded[path] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		_, skip := excluded[path]
+		if rawQuery := c.Request.URL.RawQuery; rawQuery != "" {
+			path += "?" + rawQuery
+		}
+
+		c.Next()
+
+		if c.Writer.Status() < http.StatusBadRequest && (skip || (sampleRate < 1 && rand.Float64() >= sampleRate)) {
+			return
+		}
+
+		logger.LogRequest(c.Request.Method, path, c.Writer.Status(), time.Since(start), c.ClientIP())
+	}
+}
+
+// Recovery recovers from any panics and writes a 500 JSON error if there was one.
+// Every panic is logged with its stack trace, whatever the type of the panic value.
+// When showDetail is set (development only) the panic message is included in the
+// response; the stack trace never is.
+func Recovery(showDetail bool) gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		logger.WithRequestID(c.Request.Context()).
+			WithField("stack", string(debug.Stack())).
+			Errorf("Panic recovered: %v", recovered)
+
+		response := ErrorResponse{
+			Success: false,
+			Message: "Internal Server Error",
+		}
+		if showDetail {
+			response.Error = fmt.Sprint(recovered)
+		}
+
+		abortWithError(c, http.StatusInternalServerError, response)
+	})
+}
+
+// Metrics records the count and latency of every request, labeled by method,
+// route pattern and status, and tracks how many requests are in flight.
+// Unmatched requests share a single route label.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.RequestStarted()
+		defer metrics.RequestFinished()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// Tracing starts a span for every request, continuing any trace propagated by
+// the caller. The span is stored in the request context, so the spans of the
+// service and repository calls made while handling it become its children.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := tracing.StartRequest(c.Request, route)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		tracing.EndRequest(span, c.Writer.Status())
+	}
+}
+
+// SecurityHeaders sets the given headers on every response. Headers with an empty
+// value are left out, so each can be disabled on its own.
+func SecurityHeaders(headers map[string]string) gin.HandlerFunc {
+	set := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if value != "" {
+			set[name] = value
+		}
+	}
+
+	return func(c *gin.Context) {
+		for name, value := range set {
+			c.Header(name, value)
+		}
+		c.Next()
+	}
+}
+
+// BodySizeLimit rejects request bodies larger than maxBytes with a 413. Bodies
+// announced as too large are refused upfront; others are cut off while being
+// read, which handleError reports as a 413 too. A non-positive maxBytes disables the limit.
+func BodySizeLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			abortWithError(c, http.StatusRequestEntityTooLarge, ErrorResponse{
+				Success: false,
+				Message: "Request body too large",
+				Error:   fmt.Sprintf("request body must not exceed %d bytes", maxBytes),
+			})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+// RequireJSON rejects POST, PUT and PATCH requests whose Content-Type is not JSON
+// with a 415, instead of letting them fail to bind. Any parameters, such as a
+// charset, are ignored, and JSON merge patches are accepted too. When disabled,
+// every request is let through.
+func RequireJSON(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if contentType := c.ContentType(); contentType != "application/json" && contentType != mergePatchContentType {
+				abortWithError(c, http.StatusUnsupportedMediaType, ErrorResponse{
+					Success: false,
+					Message: "Unsupported content type",
+					Error:   "Content-Type must be application/json",
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// producedMediaTypes are the media types the API responds with: JSON or XML,
+// HAL for pages of cars and CSV for exports
+var producedMediaTypes = []string{gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2, halContentType, "text/csv"}
+
+// RequireAcceptable rejects requests whose Accept header rules out every media
+// type the API produces with a 406, instead of answering with JSON regardless.
+// A missing Accept header and wildcards such as */* accept anything.
+func RequireAcceptable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Accept") != "" && c.NegotiateFormat(producedMediaTypes...) == "" {
+			abortWithError(c, http.StatusNotAcceptable, ErrorResponse{
+				Success: false,
+				Message: "Not acceptable",
+				Error:   "Accept must allow application/json or application/xml",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientLimiter is the token bucket of a single client
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimit limits every client IP to rps requests per second with bursts of up to
+// burst requests, answering 429 with a Retry-After header once the bucket is empty.
+// Buckets idle for longer than rateLimitIdleTTL are evicted. A non-positive rps disables limiting.
+func RateLimit(rps float64, burst int) gin.HandlerFunc {
+	if rps <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	var (
+		mu        sync.Mutex
+		clients   = make(map[string]*clientLimiter)
+		lastSweep = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		if now.Sub(lastSweep) > rateLimitIdleTTL {
+			for key, client := range clients {
+				if now.Sub(client.lastSeen) > rateLimitIdleTTL {
+					delete(clients, key)
+				}
+			}
+			lastSweep = now
+		}
+
+		client, ok := clients[ip]
+		if !ok {
+			client = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+			clients[ip] = client
+		}
+		client.lastSeen = now
+		reservation := client.limiter.ReserveN(now, 1)
+		mu.Unlock()
+
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			abortWithError(c, http.StatusTooManyRequests, ErrorResponse{
+				Success: false,
+				Message: "Too many requests",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Timeout bounds each request with a deadline on its context, which the
+// repository's queries honor. Handlers surface an expired deadline as a 503;
+// the middleware only writes one itself when the handler wrote nothing.
+// A non-positive timeout disables the deadline. Streaming routes such as the CSV
+// exports are exempt, as cutting them off would truncate the response mid-stream.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || isStreamingRoute(c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			logger.WithRequestID(ctx).Warnf("Request timed out after %s", timeout)
+			abortWithError(c, http.StatusServiceUnavailable, ErrorResponse{
+				Success: false,
+				Message: "Request timed out",
+			})
+		}
+	}
+}
+
+// isStreamingRoute reports whether route streams its response, e.g. a CSV export
+func isStreamingRoute(route string) bool {
+	return strings.HasSuffix(route, ".csv")
+}
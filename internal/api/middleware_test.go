@@ -0,0 +1,323 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/username/go-car-service/internal/config"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "generated when missing", incoming: ""},
+		{name: "incoming honored", incoming: "req-123", wantSame: true},
+		{name: "overlong replaced", incoming: strings.Repeat("x", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			engine := gin.New()
+			engine.Use(RequestID())
+			engine.GET("/", func(c *gin.Context) {
+				seen = logger.RequestIDFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestIDHeader, tt.incoming)
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, req)
+
+			got := recorder.Header().Get(requestIDHeader)
+			if got != seen {
+				t.Errorf("response ID %q differs from context ID %q", got, seen)
+			}
+			if tt.wantSame {
+				if got != tt.incoming {
+					t.Errorf("request ID = %q, want %q", got, tt.incoming)
+				}
+			} else if _, err := uuid.Parse(got); err != nil {
+				t.Errorf("request ID = %q, want a generated UUID", got)
+			}
+		})
+	}
+}
+
+// captureLogs redirects the logger to a buffer for the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	t.Cleanup(func() { logger.SetOutput(io.Discard) })
+	return &buf
+}
+
+// requestLogs decodes the request log lines written to buf
+func requestLogs(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", line, err)
+		}
+		if _, ok := entry["latency"]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestRequestLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		status    int
+		wantLevel string
+	}{
+		{name: "success", path: "/cars?page=2", status: http.StatusOK, wantLevel: "info"},
+		{name: "client error", path: "/cars", status: http.StatusNotFound, wantLevel: "warning"},
+		{name: "server error", path: "/cars", status: http.StatusInternalServerError, wantLevel: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLogs(t)
+			engine := gin.New()
+			engine.Use(RequestLogger(nil, 1))
+			engine.GET("/cars", func(c *gin.Context) { c.Status(tt.status) })
+
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			entries := requestLogs(t, buf)
+			if len(entries) != 1 {
+				t.Fatalf("got %d request logs, want 1: %s", len(entries), buf)
+			}
+			entry := entries[0]
+			if entry["status"] != float64(tt.status) {
+				t.Errorf("status = %v, want %d", entry["status"], tt.status)
+			}
+			if entry["path"] != tt.path || entry["method"] != http.MethodGet {
+				t.Errorf("method and path = %v %v, want GET %s", entry["method"], entry["path"], tt.path)
+			}
+			if latency, ok := entry["latency"].(float64); !ok || latency < 0 {
+				t.Errorf("latency = %v, want a duration", entry["latency"])
+			}
+			if entry["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", entry["level"], tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestRequestLoggerExcludedPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		wantLogs int
+	}{
+		{name: "excluded success", path: "/health", status: http.StatusOK, wantLogs: 0},
+		{name: "excluded with query", path: "/health?verbose=1", status: http.StatusOK, wantLogs: 0},
+		{name: "excluded failure", path: "/health", status: http.StatusServiceUnavailable, wantLogs: 1},
+		{name: "included success", path: "/cars", status: http.StatusOK, wantLogs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLogs(t)
+			engine := gin.New()
+			engine.Use(RequestLogger([]string{"/health", "/metrics"}, 1))
+			engine.GET("/health", func(c *gin.Context) { c.Status(tt.status) })
+			engine.GET("/cars", func(c *gin.Context) { c.Status(tt.status) })
+
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := len(requestLogs(t, buf)); got != tt.wantLogs {
+				t.Errorf("got %d request logs, want %d: %s", got, tt.wantLogs, buf)
+			}
+		})
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name       string
+		showDetail bool
+		wantError  string
+	}{
+		{name: "development", showDetail: true, wantError: "boom"},
+		{name: "production", showDetail: false, wantError: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLogs(t)
+			engine := gin.New()
+			engine.Use(Recovery(tt.showDetail))
+			engine.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+			if recorder.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusInternalServerError)
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if body.Success || body.Error != tt.wantError {
+				t.Errorf("body = %+v, want success false and error %q", body, tt.wantError)
+			}
+			if strings.Contains(recorder.Body.String(), "goroutine") {
+				t.Errorf("body leaks the stack trace: %s", recorder.Body)
+			}
+			if !strings.Contains(buf.String(), "Panic recovered: boom") || !strings.Contains(buf.String(), `"stack"`) {
+				t.Errorf("panic not logged with its stack trace: %s", buf)
+			}
+		})
+	}
+}
+
+func TestBodySizeLimit(t *testing.T) {
+	small := `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000}`
+	// Whitespace keeps the payload valid JSON while growing past the limit
+	large := `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000` + strings.Repeat(" ", 512) + `}`
+
+	tests := []struct {
+		name          string
+		maxBytes      int64
+		body          string
+		unknownLength bool
+		wantStatus    int
+	}{
+		{name: "within the limit", maxBytes: 256, body: small, wantStatus: http.StatusCreated},
+		{name: "announced too large", maxBytes: 256, body: large, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "too large without a length", maxBytes: 256, body: large, unknownLength: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "limit disabled", maxBytes: 0, body: large, wantStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestRouter(t, func(cfg *config.Config) { cfg.MaxRequestBodyBytes = tt.maxBytes })
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/cars", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestRecoveryPanicValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		recovered interface{}
+		wantLog   string
+	}{
+		{name: "error", recovered: errors.New("connection reset"), wantLog: "Panic recovered: connection reset"},
+		{name: "string", recovered: "boom", wantLog: "Panic recovered: boom"},
+		{name: "integer", recovered: 42, wantLog: "Panic recovered: 42"},
+		{name: "struct", recovered: struct{ Code int }{Code: 7}, wantLog: "Panic recovered: {7}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLogs(t)
+			engine := gin.New()
+			engine.Use(Recovery(false))
+			engine.GET("/panic", func(c *gin.Context) { panic(tt.recovered) })
+
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+			if recorder.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusInternalServerError)
+			}
+			if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+				t.Errorf("Content-Type = %q, want JSON", got)
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode error response %q: %v", recorder.Body, err)
+			}
+			if body.Success || body.Message == "" {
+				t.Errorf("body = %+v, want success false and a message", body)
+			}
+			if !strings.Contains(buf.String(), tt.wantLog) || !strings.Contains(buf.String(), `"stack"`) {
+				t.Errorf("log = %s, want %q with a stack trace", buf, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	body := `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000}`
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		contentType string
+		wantStatus  int
+	}{
+		{name: "json", enabled: true, contentType: "application/json", wantStatus: http.StatusCreated},
+		{name: "json with charset", enabled: true, contentType: "application/json; charset=utf-8", wantStatus: http.StatusCreated},
+		{name: "plain text", enabled: true, contentType: "text/plain", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "missing", enabled: true, contentType: "", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "plain text when disabled", enabled: false, contentType: "text/plain", wantStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestRouter(t, func(cfg *config.Config) { cfg.RequireJSONContentType = tt.enabled })
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/cars", strings.NewReader(body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("POST with Content-Type %q status = %d, want %d: %s", tt.contentType, recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+
+	t.Run("reads are not checked", func(t *testing.T) {
+		engine := newTestRouter(t, nil)
+		recorder := serve(engine, http.MethodGet, "/api/v1/cars", "", map[string]string{"Content-Type": "text/plain"})
+		if recorder.Code != http.StatusOK {
+			t.Errorf("GET status = %d, want %d", recorder.Code, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/xml"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// xmlList wraps a list payload for XML, which needs a single root element
+type xmlList struct {
+	XMLName xml.Name    `xml:"items"`
+	Items   interface{} `xml:"item"`
+}
+
+// respond writes payload with the given status, as XML when the client's Accept
+// header prefers application/xml or text/xml and as JSON otherwise
+func respond(c *gin.Context, status int, payload interface{}) {
+	switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2) {
+	case gin.MIMEXML, gin.MIMEXML2:
+		if value := reflect.ValueOf(payload); value.Kind() == reflect.Slice {
+			payload = xmlList{Items: payload}
+		}
+		c.XML(status, payload)
+	default:
+		c.JSON(status, payload)
+	}
+}
+
+// abortWithError aborts the handler chain with an error response in the
+// negotiated format
+func abortWithError(c *gin.Context, status int, response ErrorResponse) {
+	c.Abort()
+	respond(c, status, response)
+}
+
+// MarshalXML writes the error with its invalid fields as <field name="...">
+// elements, as encoding/xml cannot marshal maps
+func (e ErrorResponse) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type fieldError struct {
+		Name    string `xml:"name,attr"`
+		Problem string `xml:",chardata"`
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]fieldError, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, fieldError{Name: name, Problem: e.Fields[name]})
+	}
+
+	start.Name = xml.Name{Local: "errorResponse"}
+	return enc.EncodeElement(struct {
+		Success bool         `xml:"success"`
+		Message string       `xml:"message"`
+		Error   string       `xml:"error,omitempty"`
+		Fields  []fieldError `xml:"fields>field,omitempty"`
+	}{e.Success, e.Message, e.Error, fields}, start)
+}
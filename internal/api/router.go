@@ -1,69 +1,173 @@
 package api
 
 import (
-	"database/sql"
+	"strings"
+	"time"
+
 	"github.com/gin-contrib/cors"
+	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/username/go-car-service/internal/config"
+	"github.com/username/go-car-service/internal/events"
 	"github.com/username/go-car-service/internal/repository"
 	"github.com/username/go-car-service/internal/service"
 	"github.com/username/go-car-service/pkg/logger"
+	"github.com/username/go-car-service/pkg/metrics"
 )
 
-// SetupRouter configures and returns the Gin router
-func SetupRouter(engine *gin.Engine, db *sql.DB) {
+// NewEventPublisher returns the publisher delivering inventory events to the
+// configured webhook, or discarding them when there is none
+func NewEventPublisher(cfg *config.Config) events.EventPublisher {
+	if cfg.WebhookURL == "" {
+		return events.NoopPublisher{}
+	}
+	return events.NewHTTPPublisher(cfg.WebhookURL, cfg.WebhookMaxRetries, time.Duration(cfg.WebhookTimeoutSeconds)*time.Second)
+}
+
+// NewCarService builds the car service shared by the handlers and the background
+// jobs, so both audit, publish and cache the same way
+func NewCarService(store repository.Store, cfg *config.Config, publisher events.EventPublisher) service.CarService {
+	carService := service.NewAuditingCarService(service.NewCarService(store.Cars(), store, cfg), store.Audit())
+	carService = service.NewPublishingCarService(carService, publisher)
+	if cfg.CacheEnabled {
+		carService = service.NewCachingCarService(carService, time.Duration(cfg.CacheTTLSeconds)*time.Second, cfg.CacheMaxEntries)
+	}
+	return service.NewTracingCarService(carService)
+}
+
+// SetupRouter configures and returns the Gin router, serving cars through carService.
+// Middleware must be registered before the routes, as gin only applies it to routes added afterwards.
+func SetupRouter(engine *gin.Engine, store repository.Store, cfg *config.Config, carService service.CarService) {
+	// Only take the client IP from X-Forwarded-For when the request comes through
+	// one of our own proxies; with none configured, the peer address is used.
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatalf("Failed to set trusted proxies: %v", err)
+	}
+
+	// Tag every request with an ID for log correlation
+	engine.Use(RequestID())
+
+	// Log requests, skipping successful probes on the excluded paths and sampling other successful ones
+	engine.Use(RequestLogger(prefixPaths(cfg.BasePath, cfg.LogExcludePaths), cfg.RequestLogSampleRate))
+
+	// Record request metrics
+	engine.Use(Metrics())
+
+	// Trace requests through the handler, service and repository layers
+	engine.Use(Tracing())
+
+	// Harden responses; HSTS only makes sense behind the TLS of a production deployment
+	securityHeaders := map[string]string{
+		"X-Content-Type-Options": cfg.ContentTypeOptions,
+		"X-Frame-Options":        cfg.FrameOptions,
+		"Referrer-Policy":        cfg.ReferrerPolicy,
+	}
+	if cfg.Environment == "production" {
+		securityHeaders["Strict-Transport-Security"] = cfg.StrictTransportSecurity
+	}
+	engine.Use(SecurityHeaders(securityHeaders))
+
+	// Turn away new requests once the server starts draining; in-flight ones finish
+	engine.Use(RejectDuringShutdown(time.Duration(cfg.ShutdownDrainSeconds+cfg.ShutdownTimeoutSeconds) * time.Second))
+
+	// Recovery middleware recovers from any panics and writes a 500 if there was one.
+	engine.Use(Recovery(cfg.IsDevelopment()))
+
 	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	engine.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	origins := parseOrigins(cfg.CORSAllowedOrigins)
+	switch {
+	case len(origins) == 1 && origins[0] == "*", len(origins) == 0 && cfg.IsDevelopment():
+		corsConfig.AllowAllOrigins = true
+	case len(origins) > 0:
+		corsConfig.AllowOrigins = origins
+	default:
+		logger.Warn("CORS_ALLOWED_ORIGINS is not set; cross-origin requests are rejected")
+		corsConfig.AllowOriginFunc = func(string) bool { return false }
+	}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-None-Match", "If-Match", idempotencyKeyHeader, requestIDHeader}
+	corsConfig.ExposeHeaders = []string{requestIDHeader, "ETag", "Location", "Retry-After", resultsCappedHeader}
+	engine.Use(cors.New(corsConfig))
+
+	// Compress responses for clients accepting gzip. Small responses are not worth
+	// it, and /metrics is left alone as promhttp negotiates its own compression.
+	if cfg.CompressionEnabled {
+		engine.Use(gzip.Gzip(
+			gzip.DefaultCompression,
+			gzip.WithMinLength(cfg.CompressionMinBytes),
+			gzip.WithExcludedExtensions([]string{".png", ".gif", ".jpeg", ".jpg", ".gz", ".zip"}),
+			gzip.WithExcludedPaths([]string{cfg.BasePath + "/metrics"}),
+		))
+	}
+
+	// Every route lives under the base path, for deployments behind a path-prefix proxy
+	root := engine.Group(cfg.BasePath)
 
 	// Health check endpoint
-	engine.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
+	root.GET("/health", func(c *gin.Context) {
+		respond(c, 200, gin.H{
 			"status": "ok",
 		})
 	})
 
-	// API v1 routes
-	apiV1 := engine.Group("/api/v1")
+	// Reports whether the schema is fully migrated
+	root.GET("/health/migrations", MigrationHealth(store))
 
+	// Prometheus metrics endpoint
+	metrics.RegisterDBStats(store.Stats)
+	root.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	// Initialize repositories
-	carRepo := repository.NewCarRepository(db)
+	// API v1 routes, rate limited per client IP, with bounded JSON request bodies, JSON responses and a deadline
+	apiV1 := root.Group("/api/v1")
+	apiV1.Use(RateLimit(cfg.RateLimitRPS, cfg.RateLimitBurst))
+	apiV1.Use(RequireJSON(cfg.RequireJSONContentType))
+	apiV1.Use(RequireAcceptable())
+	apiV1.Use(BodySizeLimit(cfg.MaxRequestBodyBytes))
+	apiV1.Use(Timeout(time.Duration(cfg.RequestTimeoutSeconds) * time.Second))
+
+
+	registerJSONFieldNames()
 
 	// Initialize services
-	carService := service.NewCarService(carRepo)
+	auditService := service.NewAuditService(store.Audit())
 
 	// Initialize handlers
-	carHandler := NewCarHandler(carService)
+	carHandler := NewCarHandler(carService, cfg.StrictJSON)
+	auditHandler := NewAuditHandler(auditService)
 
 	// Register routes
-	carHandler.RegisterRoutes(apiV1)
+	requireAdmin := RequireAdmin(cfg.JWTSecret)
+	carHandler.RegisterRoutes(apiV1, requireAdmin)
+	auditHandler.RegisterRoutes(apiV1, requireAdmin)
 
 
 	// 404 handler
 	engine.NoRoute(func(c *gin.Context) {
-		c.JSON(404, gin.H{
+		respond(c, 404, gin.H{
 			"success": false,
 			"message": "Endpoint not found",
 		})
 	})
+}
 
-	// Log all requests
-	engine.Use(gin.LoggerWithConfig(gin.LoggerConfig{
-		Output: logger.GetLogger().Writer(),
-	}))
+// prefixPaths prepends the base path to each path
+func prefixPaths(basePath string, paths []string) []string {
+	prefixed := make([]string, 0, len(paths))
+	for _, path := range paths {
+		prefixed = append(prefixed, basePath+path)
+	}
+	return prefixed
+}
 
-	// Recovery middleware recovers from any panics and writes a 500 if there was one.
-	engine.Use(gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		if err, ok := recovered.(string); ok {
-			logger.Errorf("Panic recovered: %s", err)
-			c.JSON(500, ErrorResponse{
-				Success: false,
-				Message: "Internal Server Error",
-			})
+// parseOrigins splits a comma-separated list of origins, dropping empty entries
+func parseOrigins(value string) []string {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
 		}
-		c.AbortWithStatus(500)
-	}))
+	}
+	return origins
 }
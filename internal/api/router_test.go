@@ -0,0 +1,269 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/username/go-car-service/internal/config"
+	"github.com/username/go-car-service/internal/repository"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// TestMain runs the tests from the repository root, where the migrations live
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.InitLogger()
+	logger.SetOutput(io.Discard)
+
+	if err := os.Chdir("../.."); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to change to the repository root: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestRouter wires the router to a fresh in-memory store, with the default
+// configuration adjusted by configure
+func newTestRouter(t *testing.T, configure func(cfg *config.Config)) *gin.Engine {
+	t.Helper()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	cfg.DBDriver = "sqlite3"
+	cfg.RateLimitRPS = 1000
+	cfg.RateLimitBurst = 1000
+	if configure != nil {
+		configure(cfg)
+	}
+
+	store, err := repository.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	// Every router registers its own pool metrics
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+
+	engine := gin.New()
+	SetupRouter(engine, store, cfg, NewCarService(store, cfg, NewEventPublisher(cfg)))
+	return engine
+}
+
+// serve sends a request with an optional JSON body through the router
+func serve(engine *gin.Engine, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRouterEndToEnd(t *testing.T) {
+	engine := newTestRouter(t, nil)
+	civic := `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000, "year": 2020}`
+	idempotent := map[string]string{idempotencyKeyHeader: "create-civic"}
+
+	created := serve(engine, http.MethodPost, "/api/v1/cars", civic, idempotent)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("POST /cars status = %d, want %d: %s", created.Code, http.StatusCreated, created.Body)
+	}
+	var car struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(created.Body.Bytes(), &car); err != nil {
+		t.Fatalf("failed to decode created car: %v", err)
+	}
+	location := created.Header().Get("Location")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "health", method: http.MethodGet, path: "/health", wantStatus: http.StatusOK, wantBody: `"ok"`},
+		{name: "get created car", method: http.MethodGet, path: location, wantStatus: http.StatusOK, wantBody: `"Civic"`},
+		{name: "list cars", method: http.MethodGet, path: "/api/v1/cars", wantStatus: http.StatusOK, wantBody: `"Civic"`},
+		{name: "get by name", method: http.MethodGet, path: "/api/v1/cars/name/Civic", wantStatus: http.StatusOK, wantBody: `"Honda"`},
+		{name: "search", method: http.MethodGet, path: "/api/v1/cars/search?q=civ", wantStatus: http.StatusOK, wantBody: `"Civic"`},
+		{name: "replay create", method: http.MethodPost, path: "/api/v1/cars", body: civic, headers: idempotent, wantStatus: http.StatusCreated, wantBody: fmt.Sprintf(`"id":%d`, car.ID)},
+		{name: "duplicate name", method: http.MethodPost, path: "/api/v1/cars", body: civic, wantStatus: http.StatusConflict},
+		{name: "invalid payload", method: http.MethodPost, path: "/api/v1/cars", body: `{"name": "Fit"}`, wantStatus: http.StatusBadRequest},
+		{name: "delete", method: http.MethodDelete, path: location, wantStatus: http.StatusNoContent},
+		{name: "get deleted car", method: http.MethodGet, path: location, wantStatus: http.StatusNotFound},
+		{name: "reuse deleted name", method: http.MethodPost, path: "/api/v1/cars", body: civic, wantStatus: http.StatusCreated},
+		{name: "validate taken name", method: http.MethodPost, path: "/api/v1/cars/validate-field", body: `{"field": "name", "value": "Civic"}`, wantStatus: http.StatusOK, wantBody: `"valid":false`},
+		{name: "validate unknown field", method: http.MethodPost, path: "/api/v1/cars/validate-field", body: `{"field": "color", "value": "red"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown route", method: http.MethodGet, path: "/api/v1/trucks", wantStatus: http.StatusNotFound},
+	}
+
+	// The cases run in order, each on the state the previous ones left
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(engine, tt.method, tt.path, tt.body, tt.headers)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("%s %s status = %d, want %d: %s", tt.method, tt.path, recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("%s %s body = %s, want it to contain %s", tt.method, tt.path, recorder.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestFeaturedCarRoutes(t *testing.T) {
+	engine := newTestRouter(t, nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "no featured cars", method: http.MethodGet, path: "/api/v1/cars/featured/random", wantStatus: http.StatusNotFound},
+		{name: "negative weight", method: http.MethodPost, path: "/api/v1/cars", body: `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000, "featured": true, "weight": -1}`, wantStatus: http.StatusBadRequest},
+		{name: "featured car", method: http.MethodPost, path: "/api/v1/cars", body: `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000, "featured": true, "weight": 3}`, wantStatus: http.StatusCreated},
+		{name: "random featured car", method: http.MethodGet, path: "/api/v1/cars/featured/random", wantStatus: http.StatusOK},
+	}
+
+	// The cases run in order, each on the state the previous ones left
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(engine, tt.method, tt.path, tt.body, nil)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %d, want %d: %s", tt.method, tt.path, recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+}
+
+// mustCreateCar creates a car through the router and returns its location
+func mustCreateCar(t *testing.T, engine *gin.Engine, body string) string {
+	t.Helper()
+
+	recorder := serve(engine, http.MethodPost, "/api/v1/cars", body, nil)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("POST /cars status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body)
+	}
+
+	return recorder.Header().Get("Location")
+}
+
+func TestGetCarETag(t *testing.T) {
+	engine := newTestRouter(t, nil)
+	location := mustCreateCar(t, engine, `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000}`)
+	etag := serve(engine, http.MethodGet, location, "", nil).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET returned no ETag")
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "no validator", wantStatus: http.StatusOK},
+		{name: "matching", ifNoneMatch: etag, wantStatus: http.StatusNotModified},
+		{name: "weak match", ifNoneMatch: "W/" + etag, wantStatus: http.StatusNotModified},
+		{name: "match in a list", ifNoneMatch: `"stale", ` + etag, wantStatus: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "stale", ifNoneMatch: `"stale"`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.ifNoneMatch != "" {
+				headers["If-None-Match"] = tt.ifNoneMatch
+			}
+
+			recorder := serve(engine, http.MethodGet, location, "", headers)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+			if gotBody := recorder.Body.Len() > 0; gotBody != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("body = %q for status %d", recorder.Body, recorder.Code)
+			}
+		})
+	}
+
+	// Any change to the car invalidates the previous ETag
+	update := `{"name": "Civic", "brand": "Honda", "manufacturing_value": 26000, "version": 1}`
+	if recorder := serve(engine, http.MethodPut, location, update, nil); recorder.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+	}
+	recorder := serve(engine, http.MethodGet, location, "", map[string]string{"If-None-Match": etag})
+	if recorder.Code != http.StatusOK || recorder.Header().Get("ETag") == etag {
+		t.Errorf("after update: status = %d and ETag = %q, want %d and a new ETag", recorder.Code, recorder.Header().Get("ETag"), http.StatusOK)
+	}
+}
+
+func TestHeadCar(t *testing.T) {
+	engine := newTestRouter(t, nil)
+	location := mustCreateCar(t, engine, `{"name": "Civic", "brand": "Honda", "manufacturing_value": 25000}`)
+	etag := serve(engine, http.MethodGet, location, "", nil).Header().Get("ETag")
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		wantStatus  int
+		wantETag    bool
+	}{
+		{name: "existing car", path: location, wantStatus: http.StatusOK, wantETag: true},
+		{name: "not modified", path: location, ifNoneMatch: etag, wantStatus: http.StatusNotModified, wantETag: true},
+		{name: "missing car", path: "/api/v1/cars/999999", wantStatus: http.StatusNotFound},
+		{name: "invalid ID", path: "/api/v1/cars/abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.ifNoneMatch != "" {
+				headers["If-None-Match"] = tt.ifNoneMatch
+			}
+
+			recorder := serve(engine, http.MethodHead, tt.path, "", headers)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("HEAD %s status = %d, want %d", tt.path, recorder.Code, tt.wantStatus)
+			}
+			if recorder.Body.Len() != 0 {
+				t.Errorf("HEAD %s body = %q, want none", tt.path, recorder.Body)
+			}
+			if got := recorder.Header().Get("ETag"); (got == etag) != tt.wantETag {
+				t.Errorf("HEAD %s ETag = %q, want it set: %t", tt.path, got, tt.wantETag)
+			}
+		})
+	}
+}
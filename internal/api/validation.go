@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// validationFailedMessage is the message of responses rejecting an invalid payload
+const validationFailedMessage = "validation failed"
+
+// registerJSONFieldNames makes the binding validator report fields by their JSON
+// name, so validation errors name fields the way clients send them
+func registerJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// unknownFieldError reports a payload field rejected by strict JSON binding
+type unknownFieldError struct {
+	Field string
+}
+
+func (e *unknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.Field)
+}
+
+// validationFields maps each invalid field to a human-readable message. It reports
+// false when err is not a validation error. Errors of a bulk payload are keyed by
+// item index, e.g. "[2].name".
+func validationFields(err error) (map[string]string, bool) {
+	var unknownErr *unknownFieldError
+	if errors.As(err, &unknownErr) {
+		return map[string]string{unknownErr.Field: "unknown field"}, true
+	}
+
+	var sliceErrs binding.SliceValidationError
+	if errors.As(err, &sliceErrs) {
+		fields := make(map[string]string)
+		for i, itemErr := range sliceErrs {
+			var validationErrs validator.ValidationErrors
+			if !errors.As(itemErr, &validationErrs) {
+				return nil, false
+			}
+			for _, fieldErr := range validationErrs {
+				fields[fmt.Sprintf("[%d].%s", i, fieldErr.Field())] = validationMessage(fieldErr)
+			}
+		}
+		return fields, true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields[fieldErr.Field()] = validationMessage(fieldErr)
+	}
+	return fields, true
+}
+
+// validationMessage describes the rule a field failed
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "required"
+	case "gt":
+		return "must be > " + fieldErr.Param()
+	case "gte":
+		return "must be >= " + fieldErr.Param()
+	case "lt":
+		return "must be < " + fieldErr.Param()
+	case "lte":
+		return "must be <= " + fieldErr.Param()
+	case "min":
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		return "must be at most " + fieldErr.Param()
+	case "oneof":
+		return "must be one of " + fieldErr.Param()
+	default:
+		return "failed " + fieldErr.Tag() + " validation"
+	}
+}
@@ -1,41 +1,209 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 )
 
+// schemaMaxManufacturingValue is the exclusive bound enforced by the CHECK
+// constraint on cars.manufacturing_value
+const schemaMaxManufacturingValue = 15000000
+
 // Config holds all configuration for the application
 type Config struct {
-	ServerPort     string
-	DBHost         string
-	DBPort         string
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	DBSSLMode      string
-	JWTSecret      string
-	Environment    string
+	ServerHost    string
+	ServerPort    string
+	DBDriver      string
+	DBHost        string
+	DBPort        string
+	DBUser        string
+	DBPassword    string
+	DBName        string
+	DBSSLMode     string
+	DBSSLRootCert string
+	DBSSLCert     string
+	DBSSLKey      string
+	JWTSecret     string
+	Environment   string
+
+	DBStatementTimeoutMs             int
+	DBReadRetryAttempts              int
+	DBReadRetryBaseDelayMs           int
+	SlowQueryThresholdMs             int
+	ScheduledDeletionIntervalSeconds int
+	PurgeIntervalHours               int
+	PurgeRetentionDays               int
+	IdempotencyPurgeIntervalMinutes  int
+	NormalizeDescription             bool
+	NormalizeBrands                  bool
+	UniqueByNameBrand                bool
+	MaxDescriptionLength             int
+	LogExcludePaths                  []string
+	RequestLogSampleRate             float64
+	CORSAllowedOrigins               string
+	SearchMaxResults                 int
+	RateLimitRPS                     float64
+	RateLimitBurst                   int
+	MaxRequestBodyBytes              int64
+	LogLevel                         string
+	LogFormat                        string
+	ShutdownTimeoutSeconds           int
+	ShutdownDrainSeconds             int
+	CacheEnabled                     bool
+	CacheTTLSeconds                  int
+	CacheMaxEntries                  int
+	DefaultPageSize                  int
+	MaxPageSize                      int
+	CompressionEnabled               bool
+	CompressionMinBytes              int
+	RequestTimeoutSeconds            int
+	MinManufacturingValue            float64
+	MaxManufacturingValue            float64
+	StrictJSON                       bool
+	RequireJSONContentType           bool
+	TrustedProxies                   []string
+	OTelExporterEndpoint             string
+	BasePath                         string
+	ContentTypeOptions               string
+	FrameOptions                     string
+	ReferrerPolicy                   string
+	StrictTransportSecurity          string
+	WebhookURL                       string
+	WebhookMaxRetries                int
+	WebhookTimeoutSeconds            int
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
 	// Set default values
 	cfg := &Config{
-		ServerPort:  getEnv("SERVER_PORT", "8080"),
-		DBHost:      getEnv("DB_HOST", "localhost"),
-		DBPort:      getEnv("DB_PORT", "5432"),
-		DBUser:      getEnv("DB_USER", "john"),
-		DBPassword:  getEnv("DB_PASSWORD", "doe"),
-		DBName:      getEnv("DB_NAME", "car_service"),
-		DBSSLMode:   getEnv("DB_SSLMODE", "disable"),
-		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key"),
-		Environment: getEnv("ENVIRONMENT", "development"),
+		ServerHost:    getEnv("SERVER_HOST", ""),
+		ServerPort:    getEnv("SERVER_PORT", "8080"),
+		DBDriver:      getEnv("DB_DRIVER", "postgres"),
+		DBHost:        getEnv("DB_HOST", "localhost"),
+		DBPort:        getEnv("DB_PORT", "5432"),
+		DBUser:        getEnv("DB_USER", "john"),
+		DBPassword:    getEnv("DB_PASSWORD", "doe"),
+		DBName:        getEnv("DB_NAME", "car_service"),
+		DBSSLMode:     getEnv("DB_SSLMODE", "disable"),
+		DBSSLRootCert: getEnv("DB_SSLROOTCERT", ""),
+		DBSSLCert:     getEnv("DB_SSLCERT", ""),
+		DBSSLKey:      getEnv("DB_SSLKEY", ""),
+		JWTSecret:     getEnv("JWT_SECRET", defaultJWTSecret),
+		Environment:   getEnv("ENVIRONMENT", "development"),
+
+		DBStatementTimeoutMs:             getEnvAsInt("DB_STATEMENT_TIMEOUT_MS", 5000),
+		DBReadRetryAttempts:              getEnvAsInt("DB_READ_RETRY_ATTEMPTS", 3),
+		DBReadRetryBaseDelayMs:           getEnvAsInt("DB_READ_RETRY_BASE_DELAY_MS", 100),
+		SlowQueryThresholdMs:             getEnvAsInt("SLOW_QUERY_THRESHOLD_MS", 500),
+		ScheduledDeletionIntervalSeconds: getEnvAsInt("SCHEDULED_DELETION_INTERVAL_SECONDS", 60),
+		PurgeIntervalHours:               getEnvAsInt("PURGE_INTERVAL_HOURS", 0),
+		PurgeRetentionDays:               getEnvAsInt("PURGE_RETENTION_DAYS", 30),
+		IdempotencyPurgeIntervalMinutes:  getEnvAsInt("IDEMPOTENCY_PURGE_INTERVAL_MINUTES", 60),
+		NormalizeDescription:             getEnvAsBool("NORMALIZE_DESCRIPTION", false),
+		NormalizeBrands:                  getEnvAsBool("NORMALIZE_BRANDS", false),
+		UniqueByNameBrand:                getEnvAsBool("UNIQUE_BY_NAME_BRAND", false),
+		MaxDescriptionLength:             getEnvAsInt("MAX_DESCRIPTION_LENGTH", 2000),
+		LogExcludePaths:                  getEnvAsSlice("LOG_EXCLUDE_PATHS", []string{"/health", "/metrics"}),
+		RequestLogSampleRate:             getEnvAsFloat("REQUEST_LOG_SAMPLE_RATE", 1),
+		CORSAllowedOrigins:               getEnv("CORS_ALLOWED_ORIGINS", ""),
+		SearchMaxResults:                 getEnvAsInt("SEARCH_MAX_RESULTS", 1000),
+		RateLimitRPS:                     getEnvAsFloat("RATE_LIMIT_RPS", 10),
+		RateLimitBurst:                   getEnvAsInt("RATE_LIMIT_BURST", 20),
+		MaxRequestBodyBytes:              int64(getEnvAsInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+		LogLevel:                         getEnv("LOG_LEVEL", "info"),
+		LogFormat:                        getEnv("LOG_FORMAT", "json"),
+		ShutdownTimeoutSeconds:           getEnvAsInt("SHUTDOWN_TIMEOUT_SECONDS", 5),
+		ShutdownDrainSeconds:             getEnvAsInt("SHUTDOWN_DRAIN_SECONDS", 0),
+		CacheEnabled:                     getEnvAsBool("CACHE_ENABLED", false),
+		CacheTTLSeconds:                  getEnvAsInt("CACHE_TTL_SECONDS", 60),
+		CacheMaxEntries:                  getEnvAsInt("CACHE_MAX_ENTRIES", 1000),
+		DefaultPageSize:                  getEnvAsInt("DEFAULT_PAGE_SIZE", 10),
+		MaxPageSize:                      getEnvAsInt("MAX_PAGE_SIZE", 100),
+		CompressionEnabled:               getEnvAsBool("COMPRESSION_ENABLED", false),
+		CompressionMinBytes:              getEnvAsInt("COMPRESSION_MIN_BYTES", 1024),
+		RequestTimeoutSeconds:            getEnvAsInt("REQUEST_TIMEOUT_SECONDS", 15),
+		MinManufacturingValue:            getEnvAsFloat("MIN_MANUFACTURING_VALUE", 0),
+		MaxManufacturingValue:            getEnvAsFloat("MAX_MANUFACTURING_VALUE", 15000000),
+		StrictJSON:                       getEnvAsBool("STRICT_JSON", false),
+		RequireJSONContentType:           getEnvAsBool("REQUIRE_JSON_CONTENT_TYPE", true),
+		TrustedProxies:                   getEnvAsSlice("TRUSTED_PROXIES", nil),
+		OTelExporterEndpoint:             getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		BasePath:                         normalizeBasePath(getEnv("BASE_PATH", "")),
+		ContentTypeOptions:               getEnv("SECURITY_CONTENT_TYPE_OPTIONS", "nosniff"),
+		FrameOptions:                     getEnv("SECURITY_FRAME_OPTIONS", "DENY"),
+		ReferrerPolicy:                   getEnv("SECURITY_REFERRER_POLICY", "no-referrer"),
+		StrictTransportSecurity:          getEnv("SECURITY_HSTS", "max-age=31536000; includeSubDomains"),
+		WebhookURL:                       getEnv("WEBHOOK_URL", ""),
+		WebhookMaxRetries:                getEnvAsInt("WEBHOOK_MAX_RETRIES", 3),
+		WebhookTimeoutSeconds:            getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 5),
+	}
+
+	if cfg.DefaultPageSize < 1 || cfg.MaxPageSize < cfg.DefaultPageSize {
+		return nil, fmt.Errorf("invalid page sizes: DEFAULT_PAGE_SIZE (%d) must be at least 1 and at most MAX_PAGE_SIZE (%d)", cfg.DefaultPageSize, cfg.MaxPageSize)
+	}
+
+	if cfg.MinManufacturingValue < 0 || cfg.MaxManufacturingValue <= cfg.MinManufacturingValue {
+		return nil, fmt.Errorf("invalid manufacturing value bounds: MIN_MANUFACTURING_VALUE (%g) must not be negative and must be below MAX_MANUFACTURING_VALUE (%g)", cfg.MinManufacturingValue, cfg.MaxManufacturingValue)
+	}
+
+	if cfg.MaxManufacturingValue > schemaMaxManufacturingValue {
+		return nil, fmt.Errorf("invalid MAX_MANUFACTURING_VALUE %g: the database rejects values of %d or more", cfg.MaxManufacturingValue, schemaMaxManufacturingValue)
+	}
+
+	if cfg.PurgeIntervalHours > 0 && cfg.PurgeRetentionDays < 1 {
+		return nil, fmt.Errorf("invalid PURGE_RETENTION_DAYS %d: must be at least 1 while purging is enabled", cfg.PurgeRetentionDays)
+	}
+
+	if cfg.RequestLogSampleRate < 0 || cfg.RequestLogSampleRate > 1 {
+		return nil, fmt.Errorf("invalid REQUEST_LOG_SAMPLE_RATE %g: must be between 0 and 1", cfg.RequestLogSampleRate)
+	}
+
+	// Anyone can sign admin tokens with a published secret, so only development may use one
+	if !cfg.IsDevelopment() && isPlaceholderJWTSecret(cfg.JWTSecret) {
+		return nil, fmt.Errorf("invalid JWT_SECRET: a secret of your own is required outside development")
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: expected an IP address or CIDR", proxy)
+		}
 	}
 
 	return cfg, nil
 }
 
+// defaultJWTSecret is the JWT secret used when none is set, fit for development only
+const defaultJWTSecret = "your-secret-key"
+
+// isPlaceholderJWTSecret reports whether secret is empty or one of the secrets
+// published in the defaults and the sample .env
+func isPlaceholderJWTSecret(secret string) bool {
+	switch strings.TrimSpace(secret) {
+	case "", defaultJWTSecret, "your-secret-key-here":
+		return true
+	}
+	return false
+}
+
+// IsDevelopment reports whether the application runs in the development environment
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "development"
+}
+
+// normalizeBasePath turns a path prefix such as "car-service/" into "/car-service".
+// The root path becomes empty.
+func normalizeBasePath(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -53,6 +221,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsFloat gets an environment variable as float or returns a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 // getEnvAsBool gets an environment variable as boolean or returns a default value
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if val := getEnv(key, ""); val != "" {
@@ -62,3 +239,20 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvAsSlice gets a comma-separated environment variable as a slice or returns a default value.
+// Set the variable to an empty string for an empty slice.
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	valueStr, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	values := make([]string, 0)
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigJWTSecret(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		secret      string
+		wantErr     bool
+	}{
+		{name: "default secret in development", environment: "development", secret: defaultJWTSecret},
+		{name: "default secret in production", environment: "production", secret: defaultJWTSecret, wantErr: true},
+		{name: "sample secret in production", environment: "production", secret: "your-secret-key-here", wantErr: true},
+		{name: "empty secret in production", environment: "production", secret: "", wantErr: true},
+		{name: "own secret in production", environment: "production", secret: "s3cr3t-of-our-own"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tt.environment)
+			t.Setenv("JWT_SECRET", tt.secret)
+
+			cfg, err := LoadConfig()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") {
+					t.Fatalf("LoadConfig() error = %v, want a JWT_SECRET error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if cfg.JWTSecret != tt.secret {
+				t.Errorf("JWTSecret = %q, want %q", cfg.JWTSecret, tt.secret)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package errs
+
+import "errors"
+
+var (
+	// ErrCarNotFound is returned when a car does not exist or has been deleted
+	ErrCarNotFound = errors.New("car not found")
+
+	// ErrDuplicateCar is returned when a car with the same name already exists
+	ErrDuplicateCar = errors.New("car already exists")
+
+	// ErrInvalidInput is returned when a request fails validation
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrVersionConflict is returned when a car was changed since the version the update is based on
+	ErrVersionConflict = errors.New("car was modified concurrently")
+
+	// ErrUnknownField is returned when validating a field that has no validation rule
+	ErrUnknownField = errors.New("unknown field")
+)
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// Types of the inventory events published to downstream systems
+const (
+	CarCreated = "car.created"
+	CarDeleted = "car.deleted"
+	CarPurged  = "car.purged"
+)
+
+// webhookRetryBaseDelay is the wait before the first retry of a failed delivery;
+// it doubles with every further attempt
+const webhookRetryBaseDelay = time.Second
+
+// Event is an inventory change. Car is set for created cars and nil for deleted ones.
+type Event struct {
+	Type       string             `json:"type"`
+	CarID      int64              `json:"carId"`
+	Car        *model.CarResponse `json:"car,omitempty"`
+	OccurredAt time.Time          `json:"occurredAt"`
+}
+
+// EventPublisher notifies downstream systems of inventory changes. Publish must
+// not block on delivery, and delivery failures are not reported to the caller.
+// Close waits for the deliveries still in flight, giving up once ctx is done.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event)
+	Close(ctx context.Context) error
+}
+
+// NoopPublisher discards every event; it is used when no webhook is configured
+type NoopPublisher struct{}
+
+// Publish discards the event
+func (NoopPublisher) Publish(context.Context, Event) {}
+
+// Close has nothing to wait for
+func (NoopPublisher) Close(context.Context) error { return nil }
+
+// HTTPPublisher POSTs every event as JSON to a webhook URL in the background,
+// retrying failed deliveries with exponential backoff
+type HTTPPublisher struct {
+	url        string
+	maxRetries int
+	client     *http.Client
+	inFlight   sync.WaitGroup
+}
+
+// NewHTTPPublisher creates an HTTPPublisher delivering to url. A failed delivery
+// is retried up to maxRetries times before the event is dropped.
+func NewHTTPPublisher(url string, maxRetries int, timeout time.Duration) *HTTPPublisher {
+	return &HTTPPublisher{
+		url:        url,
+		maxRetries: max(maxRetries, 0),
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish delivers the event in a new goroutine. The delivery outlives the
+// request that triggered it but keeps its request ID for logging.
+func (p *HTTPPublisher) Publish(ctx context.Context, event Event) {
+	p.inFlight.Add(1)
+	go func() {
+		defer p.inFlight.Done()
+		p.deliver(context.WithoutCancel(ctx), event)
+	}()
+}
+
+// Close waits for the deliveries in flight, including their retries, until ctx is done
+func (p *HTTPPublisher) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries still in flight: %w", ctx.Err())
+	}
+}
+
+// deliver posts the event until it is accepted or the retries are exhausted
+func (p *HTTPPublisher) deliver(ctx context.Context, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.WithRequestID(ctx).Errorf("Failed to encode %s event for car %d: %v", event.Type, event.CarID, err)
+		return
+	}
+
+	delay := webhookRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err = p.post(ctx, body)
+		if err == nil {
+			return
+		}
+
+		if attempt == p.maxRetries {
+			logger.WithRequestID(ctx).Errorf("Dropping %s event for car %d after %d attempts: %v", event.Type, event.CarID, attempt+1, err)
+			return
+		}
+
+		logger.WithRequestID(ctx).Warnf("Failed to deliver %s event for car %d, retrying in %s: %v", event.Type, event.CarID, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends a single delivery attempt; any non-2xx response is a failure
+func (p *HTTPPublisher) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", logger.RequestIDFromContext(ctx))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// RunPeriodic calls fn every interval until ctx is cancelled.
+// A non-positive interval disables the job.
+func RunPeriodic(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	if interval <= 0 {
+		logger.Infof("Background job %s is disabled", name)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Infof("Background job %s started (interval %s)", name, interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Infof("Background job %s stopped", name)
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				logger.Errorf("Background job %s failed: %v", name, err)
+			}
+		}
+	}
+}
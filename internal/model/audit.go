@@ -0,0 +1,74 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Audit event types
+const (
+	AuditEventCreated = "car.created"
+	AuditEventUpdated = "car.updated"
+	AuditEventDeleted = "car.deleted"
+	AuditEventMerged  = "car.merged"
+	AuditEventPurged  = "car.purged"
+)
+
+// AuditEvent represents a recorded change to a car
+type AuditEvent struct {
+	ID        int64          `json:"id" db:"id"`
+	CarID     int64          `json:"car_id" db:"car_id"`
+	EventType string         `json:"event_type" db:"event_type"`
+	Actor     sql.NullString `json:"actor,omitempty" db:"actor"`
+	Details   sql.NullString `json:"details,omitempty" db:"details"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// AuditEventFilter narrows down a listing of audit events. Events are returned
+// in ascending ID order starting after AfterID. A zero CarID matches every car.
+type AuditEventFilter struct {
+	CarID     int64
+	EventType string
+	Since     *time.Time
+	AfterID   int64
+	Limit     int
+}
+
+// AuditEventResponse represents the response payload for an audit event
+type AuditEventResponse struct {
+	ID        int64           `json:"id"`
+	CarID     int64           `json:"car_id"`
+	EventType string          `json:"event_type"`
+	Actor     *string         `json:"actor,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty" swaggertype:"object"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// AuditEventPage represents a page of audit events with the cursor for the next page
+type AuditEventPage struct {
+	Events     []*AuditEventResponse `json:"events"`
+	NextCursor *int64                `json:"next_cursor"`
+}
+
+// ToResponse converts an AuditEvent to an AuditEventResponse
+func (e *AuditEvent) ToResponse() *AuditEventResponse {
+	var actor *string
+	if e.Actor.Valid {
+		actor = &e.Actor.String
+	}
+
+	var details json.RawMessage
+	if e.Details.Valid {
+		details = json.RawMessage(e.Details.String)
+	}
+
+	return &AuditEventResponse{
+		ID:        e.ID,
+		CarID:     e.CarID,
+		EventType: e.EventType,
+		Actor:     actor,
+		Details:   details,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+	}
+}
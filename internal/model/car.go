@@ -1,7 +1,14 @@
 package model
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -10,8 +17,15 @@ type Car struct {
 	ID                int64          `json:"id" db:"id"`
 	Name              string         `json:"name" db:"name"`
 	Brand             string         `json:"brand" db:"brand"`
-	ManufacturingValue float64        `json:"manufacturing_value" db:"manufacturing_value"`
+	ManufacturingValue Money          `json:"manufacturing_value" db:"manufacturing_value"`
 	Description       sql.NullString `json:"description,omitempty" db:"description"`
+	Color             sql.NullString `json:"color,omitempty" db:"color"`
+	Year              int            `json:"year,omitempty" db:"year"`
+	Featured          bool           `json:"featured" db:"featured"`
+	Weight            int            `json:"weight" db:"weight"`
+	DeleteScheduledAt sql.NullTime   `json:"delete_scheduled_at,omitempty" db:"delete_scheduled_at"`
+	DeletedAt         sql.NullTime   `json:"deleted_at,omitempty" db:"deleted_at"`
+	Version           int            `json:"version" db:"version"`
 	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
 }
@@ -20,27 +34,107 @@ type Car struct {
 type CarRequest struct {
 	Name              string  `json:"name" binding:"required"`
 	Brand             string  `json:"brand" binding:"required"`
-	ManufacturingValue float64 `json:"manufacturing_value" binding:"required,gt=0,lt=15000000"`
+	ManufacturingValue Money   `json:"manufacturing_value" binding:"required" swaggertype:"number"`
 	Description       *string `json:"description,omitempty"`
+	Color             *string `json:"color,omitempty" binding:"omitempty,max=50"`
+	Year              int     `json:"year,omitempty" binding:"omitempty,gte=1900,lte=2100"`
+	Featured          bool    `json:"featured"`
+	Weight            int     `json:"weight,omitempty" binding:"omitempty,gt=0"`
+	// Version is the version the update is based on; required on update, ignored on create
+	Version int `json:"version,omitempty" binding:"omitempty,gt=0"`
+}
+
+// MergeCarsRequest represents the request payload for merging a duplicate car into a primary one
+type MergeCarsRequest struct {
+	PrimaryID   int64 `json:"primaryId" binding:"required,gt=0"`
+	DuplicateID int64 `json:"duplicateId" binding:"required,gt=0"`
+}
+
+// DeleteCarsRequest represents the request payload for deleting several cars at once
+type DeleteCarsRequest struct {
+	IDs []int64 `json:"ids" binding:"required,dive,gt=0"`
+}
+
+// DeleteCarsResponse reports the outcome of deleting several cars at once
+type DeleteCarsResponse struct {
+	Deleted  int     `json:"deleted"`
+	NotFound []int64 `json:"notFound"`
+}
+
+// CarListPage represents a page of cars fetched by page number, with the total
+// number of active cars the pages are taken from
+type CarListPage struct {
+	Cars     []*CarResponse
+	Page     int
+	PageSize int
+	Total    int64
+}
+
+// CarPage represents a page of cars fetched by cursor, with the cursor of the
+// next page; NextCursor is null on the last page
+type CarPage struct {
+	Cars       []*CarResponse `json:"cars"`
+	NextCursor *int64         `json:"nextCursor"`
+}
+
+// CarExtremesResponse represents the cheapest and most expensive active cars;
+// both are null when there are no cars
+type CarExtremesResponse struct {
+	Cheapest      *CarResponse `json:"cheapest"`
+	MostExpensive *CarResponse `json:"mostExpensive"`
+}
+
+// NameAvailabilityResponse reports whether a name is free for a new car,
+// and, when names are only unique per brand, the brand it was checked for
+type NameAvailabilityResponse struct {
+	Name      string `json:"name"`
+	Brand     string `json:"brand,omitempty"`
+	Available bool   `json:"available"`
+}
+
+// RelatedCarsResponse represents a car along with other cars of its brand,
+// those closest in price first
+type RelatedCarsResponse struct {
+	Car     *CarResponse   `json:"car"`
+	Related []*CarResponse `json:"related"`
+}
+
+// CarBatchResponse represents the cars fetched by a list of IDs
+type CarBatchResponse struct {
+	Cars    []*CarResponse `json:"cars"`
+	Missing []int64        `json:"missing"`
 }
 
 // CarResponse represents the response payload for a car
 type CarResponse struct {
-	ID                int64   `json:"id"`
-	Name              string  `json:"name"`
-	Brand             string  `json:"brand"`
-	ManufacturingValue float64 `json:"manufacturing_value"`
-	Description       *string `json:"description,omitempty"`
-	CreatedAt         string  `json:"created_at"`
-	UpdatedAt         string  `json:"updated_at"`
+	XMLName            xml.Name `json:"-" xml:"car"`
+	ID                 int64    `json:"id" xml:"id"`
+	Name               string   `json:"name" xml:"name"`
+	Brand              string   `json:"brand" xml:"brand"`
+	ManufacturingValue Money    `json:"manufacturing_value" xml:"manufacturing_value" swaggertype:"number"`
+	Description        *string  `json:"description,omitempty" xml:"description,omitempty"`
+	Color              *string  `json:"color,omitempty" xml:"color,omitempty"`
+	Year               int      `json:"year,omitempty" xml:"year,omitempty"`
+	Featured           bool     `json:"featured" xml:"featured"`
+	Weight             int      `json:"weight" xml:"weight"`
+	Version            int      `json:"version" xml:"version"`
+	DeleteScheduledAt  *string  `json:"delete_scheduled_at,omitempty" xml:"delete_scheduled_at,omitempty"`
+	CreatedAt          string   `json:"created_at" xml:"created_at"`
+	UpdatedAt          string   `json:"updated_at" xml:"updated_at"`
+
+	// ETag identifies this version of the car for conditional requests
+	ETag string `json:"-" xml:"-"`
 }
 
 // ToResponse converts a Car model to a CarResponse
-toResponse(car *Car) *CarResponse {
-	var desc *string
+func (car *Car) ToResponse() *CarResponse {
+	var desc, color *string
 	if car.Description.Valid {
 		desc = &car.Description.String
 	}
+	if car.Color.Valid {
+		color = &car.Color.String
+	}
 
 	return &CarResponse{
 		ID:                car.ID,
@@ -48,23 +142,137 @@ toResponse(car *Car) *CarResponse {
 		Brand:             car.Brand,
 		ManufacturingValue: car.ManufacturingValue,
 		Description:       desc,
+		Color:             color,
+		Year:              car.Year,
+		Featured:          car.Featured,
+		Weight:            car.Weight,
+		Version:           car.Version,
+		DeleteScheduledAt: formatNullTime(car.DeleteScheduledAt),
 		CreatedAt:         car.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:         car.UpdatedAt.Format(time.RFC3339),
+		ETag:              car.ETag(),
+	}
+}
+
+// ETag returns a quoted entity tag that changes whenever the car changes. It is
+// derived from the full-precision update time, plus the scheduled deletion time
+// since scheduling a deletion does not touch updated_at.
+func (car *Car) ETag() string {
+	var scheduled int64
+	if car.DeleteScheduledAt.Valid {
+		scheduled = car.DeleteScheduledAt.Time.UnixNano()
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%d", car.ID, car.UpdatedAt.UnixNano(), scheduled)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// DeletedCarResponse represents the response payload for a car that may have been
+// soft deleted; DeletedAt is null for an active car
+type DeletedCarResponse struct {
+	CarResponse
+	DeletedAt *string `json:"deleted_at" xml:"deleted_at"`
+}
+
+// ToDeletedResponse converts a soft-deleted Car model to a DeletedCarResponse
+func (car *Car) ToDeletedResponse() *DeletedCarResponse {
+	return &DeletedCarResponse{
+		CarResponse: *car.ToResponse(),
+		DeletedAt:   formatNullTime(car.DeletedAt),
+	}
+}
+
+// CarChangeResponse represents a changed car in a sync response; Deleted cars
+// are to be removed by the client
+type CarChangeResponse struct {
+	CarResponse
+	Deleted bool `json:"deleted" xml:"deleted"`
+}
+
+// CarChangesResponse represents a page of the cars changed since a point in time.
+// NextCursor fetches the next page and is null on the last one, whose server time
+// is to be passed as the next since.
+type CarChangesResponse struct {
+	Changes    []*CarChangeResponse `json:"changes"`
+	ServerTime string               `json:"serverTime"`
+	NextCursor *string              `json:"nextCursor"`
+}
+
+// ChangeCursor marks a position in the feed of car changes, which is ordered by
+// the time each car last changed and then by ID: the changes after it are those
+// made later than ChangedAt, or at ChangedAt to a car with a higher ID
+type ChangeCursor struct {
+	ChangedAt time.Time
+	ID        int64
+}
+
+// ChangeCursorAt returns the cursor after every change made up to since
+func ChangeCursorAt(since time.Time) ChangeCursor {
+	return ChangeCursor{ChangedAt: since, ID: math.MaxInt64}
+}
+
+// ChangeCursorAfter returns the cursor right after the last change of a car
+func ChangeCursorAfter(car *Car) ChangeCursor {
+	changedAt := car.UpdatedAt
+	if car.DeletedAt.Valid && car.DeletedAt.Time.After(changedAt) {
+		changedAt = car.DeletedAt.Time
+	}
+	return ChangeCursor{ChangedAt: changedAt, ID: car.ID}
+}
+
+// String encodes the cursor as the change time in RFC 3339 and the car ID,
+// separated by an underscore
+func (c ChangeCursor) String() string {
+	return c.ChangedAt.UTC().Format(time.RFC3339Nano) + "_" + strconv.FormatInt(c.ID, 10)
+}
+
+// ParseChangeCursor decodes a cursor encoded by ChangeCursor.String
+func ParseChangeCursor(s string) (ChangeCursor, error) {
+	changedAt, id, ok := strings.Cut(s, "_")
+	if !ok {
+		return ChangeCursor{}, fmt.Errorf("invalid change cursor %q", s)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, changedAt)
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("invalid change cursor time: %w", err)
+	}
+
+	carID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || carID < 0 {
+		return ChangeCursor{}, fmt.Errorf("invalid change cursor ID %q", id)
+	}
+
+	return ChangeCursor{ChangedAt: t, ID: carID}, nil
+}
+
+// ToChangeResponse converts a Car model, deleted or not, to a CarChangeResponse
+func (car *Car) ToChangeResponse() *CarChangeResponse {
+	return &CarChangeResponse{
+		CarResponse: *car.ToResponse(),
+		Deleted:     car.DeletedAt.Valid,
 	}
 }
 
 // ToModel converts a CarRequest to a Car model
 func (cr *CarRequest) ToModel() *Car {
-	var desc sql.NullString
+	var desc, color sql.NullString
 	if cr.Description != nil {
 		desc = sql.NullString{String: *cr.Description, Valid: true}
 	}
+	if cr.Color != nil {
+		color = sql.NullString{String: *cr.Color, Valid: true}
+	}
 
 	return &Car{
 		Name:              cr.Name,
 		Brand:             cr.Brand,
 		ManufacturingValue: cr.ManufacturingValue,
 		Description:       desc,
+		Color:             color,
+		Year:              cr.Year,
+		Featured:          cr.Featured,
+		Weight:            weightOrDefault(cr.Weight),
 	}
 }
 
@@ -73,9 +281,50 @@ func (c *Car) UpdateFromRequest(req *CarRequest) {
 	c.Name = req.Name
 	c.Brand = req.Brand
 	c.ManufacturingValue = req.ManufacturingValue
+	c.Year = req.Year
+	c.Featured = req.Featured
+	c.Weight = weightOrDefault(req.Weight)
+	c.Version = req.Version
 	if req.Description != nil {
 		c.Description = sql.NullString{String: *req.Description, Valid: true}
 	} else {
 		c.Description = sql.NullString{Valid: false}
 	}
+	if req.Color != nil {
+		c.Color = sql.NullString{String: *req.Color, Valid: true}
+	} else {
+		c.Color = sql.NullString{Valid: false}
+	}
+}
+
+// ToRequest converts a Car model to the CarRequest that would update it to its
+// current state, based on its current version
+func (c *Car) ToRequest() *CarRequest {
+	req := &CarRequest{
+		Name:               c.Name,
+		Brand:              c.Brand,
+		ManufacturingValue: c.ManufacturingValue,
+		Year:               c.Year,
+		Featured:           c.Featured,
+		Weight:             c.Weight,
+		Version:            c.Version,
+	}
+	if c.Description.Valid {
+		req.Description = &c.Description.String
+	}
+	if c.Color.Valid {
+		req.Color = &c.Color.String
+	}
+	return req
+}
+
+// DefaultWeight is the featured selection weight used when none is given
+const DefaultWeight = 1
+
+// weightOrDefault returns the default weight for an unset (zero) weight
+func weightOrDefault(weight int) int {
+	if weight == 0 {
+		return DefaultWeight
+	}
+	return weight
 }
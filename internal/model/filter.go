@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// CarFilter holds the optional criteria for filtering cars.
+// Nil or empty fields are not applied; time bounds are inclusive.
+type CarFilter struct {
+	Brand         string
+	MinPrice      *float64
+	MaxPrice      *float64
+	MinYear       *int
+	MaxYear       *int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Page          int
+	PageSize      int
+}
+
+// HasPriceBounds reports whether a minimum or maximum price is set
+func (f CarFilter) HasPriceBounds() bool {
+	return f.MinPrice != nil || f.MaxPrice != nil
+}
+
+// HasTimeBounds reports whether any creation or update time bound is set
+func (f CarFilter) HasTimeBounds() bool {
+	return f.CreatedAfter != nil || f.CreatedBefore != nil || f.UpdatedAfter != nil || f.UpdatedBefore != nil
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount held as a whole number of cents, so that arithmetic on
+// prices doesn't accumulate floating point rounding errors. It is written to
+// JSON as a number with two decimals, e.g. 28500.00.
+type Money int64
+
+// MoneyFromFloat converts an amount to Money, rounding to the nearest cent
+func MoneyFromFloat(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// ParseMoney parses a decimal amount such as "28500" or "28500.99". Amounts
+// with more than two significant decimal places are rejected rather than rounded.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+
+	units, fraction, _ := strings.Cut(digits, ".")
+	if units == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	trimmed := strings.TrimRight(fraction, "0")
+	if len(trimmed) > 2 {
+		return 0, fmt.Errorf("amount %q must have at most two decimal places", s)
+	}
+	fraction = (trimmed + "00")[:2]
+
+	if units == "" {
+		units = "0"
+	}
+
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil || strings.ContainsAny(units+fraction, "+-") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if negative {
+		cents = -cents
+	}
+
+	return Money(cents), nil
+}
+
+// Float64 returns the amount in currency units
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// AdjustedBy applies a percentage change to the amount, e.g. 10 for a 10% raise.
+// The multiplier is taken as the shortest decimal of 1+percent/100 and applied in
+// exact arithmetic, rounding half away from zero to the cent, the way Postgres
+// rounds a NUMERIC.
+func (m Money) AdjustedBy(percent float64) Money {
+	factor, ok := new(big.Rat).SetString(strconv.FormatFloat(1+percent/100, 'f', -1, 64))
+	if !ok {
+		return m
+	}
+
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(m)), factor)
+	cents, remainder := new(big.Int).QuoRem(product.Num(), product.Denom(), new(big.Int))
+
+	// Round up the magnitude when the remainder is at least half the denominator
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(product.Denom()) >= 0 {
+		cents.Add(cents, big.NewInt(int64(product.Num().Sign())))
+	}
+
+	return Money(cents.Int64())
+}
+
+// String formats the amount with two decimals, e.g. "28500.00"
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// MarshalText writes the amount with two decimals, as in XML responses
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads the amount from a JSON number, keeping its exact decimal value
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if raw == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(raw, `"`) {
+		return errors.New("amount must be a number")
+	}
+
+	// Exponent notation is expanded to plain decimals first
+	if strings.ContainsAny(raw, "eE") {
+		amount, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount %q", raw)
+		}
+		raw = strconv.FormatFloat(amount, 'f', -1, 64)
+	}
+
+	parsed, err := ParseMoney(raw)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// Scan reads the amount from a DECIMAL column. Postgres returns the exact decimal
+// text; SQLite returns a number. Aggregates such as AVG are rounded to the cent.
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v * 100)
+	case float64:
+		*m = MoneyFromFloat(v)
+	case []byte:
+		return m.scanText(string(v))
+	case string:
+		return m.scanText(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+
+	return nil
+}
+
+// scanText reads a decimal amount, rounding any digits beyond cents
+func (m *Money) scanText(s string) error {
+	if parsed, err := ParseMoney(s); err == nil {
+		*m = parsed
+		return nil
+	}
+
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Money: %w", s, err)
+	}
+
+	*m = MoneyFromFloat(amount)
+	return nil
+}
+
+// Value writes the amount as exact decimal text, which both Postgres and SQLite
+// store into a DECIMAL column without loss
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
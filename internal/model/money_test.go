@@ -0,0 +1,197 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Money
+		wantErr bool
+	}{
+		{name: "two decimals", json: `19999.99`, want: 1999999},
+		{name: "one decimal", json: `19999.9`, want: 1999990},
+		{name: "integer", json: `20000`, want: 2000000},
+		{name: "trailing zeros", json: `19999.9900`, want: 1999999},
+		{name: "exponent", json: `1.99999e4`, want: 1999990},
+		{name: "negative", json: `-5.25`, want: -525},
+		{name: "three decimals", json: `19999.999`, wantErr: true},
+		{name: "tiny fraction", json: `0.001`, wantErr: true},
+		{name: "string", json: `"19999.99"`, wantErr: true},
+		{name: "not a number", json: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req struct {
+				Value Money `json:"value"`
+			}
+			err := json.Unmarshal([]byte(`{"value": `+tt.json+`}`), &req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %v, want an error", tt.json, req.Value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+			}
+			if req.Value != tt.want {
+				t.Errorf("Unmarshal(%s) = %d cents, want %d", tt.json, req.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: 2850000, want: "28500.00"},
+		{money: 2850005, want: "28500.05"},
+		{money: 1, want: "0.01"},
+		{money: 0, want: "0.00"},
+		{money: -525, want: "-5.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			data, err := json.Marshal(map[string]Money{"value": tt.money})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if want := `{"value":` + tt.want + `}`; string(data) != want {
+				t.Errorf("Marshal() = %s, want %s", data, want)
+			}
+		})
+	}
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   Money
+	}{
+		{amount: 28500, want: 2850000},
+		{amount: 19999.99, want: 1999999},
+		{amount: 0.1 + 0.2, want: 30},
+		{amount: 10.005, want: 1001},
+		{amount: 10.004, want: 1000},
+		{amount: -2.5, want: -250},
+	}
+
+	for _, tt := range tests {
+		if got := MoneyFromFloat(tt.amount); got != tt.want {
+			t.Errorf("MoneyFromFloat(%v) = %d cents, want %d", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "28500", want: 2850000},
+		{in: "28500.5", want: 2850050},
+		{in: " 28500.99 ", want: 2850099},
+		{in: ".5", want: 50},
+		{in: "7.", want: 700},
+		{in: "-1.10", want: -110},
+		{in: "1.999", wantErr: true},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.-5", wantErr: true},
+		{in: "--1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %d, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d cents, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    Money
+		wantErr bool
+	}{
+		{name: "null", value: nil, want: 0},
+		{name: "integer", value: int64(28500), want: 2850000},
+		{name: "float", value: 28500.5, want: 2850050},
+		{name: "decimal text", value: []byte("28500.99"), want: 2850099},
+		{name: "string", value: "28500.00", want: 2850000},
+		{name: "average rounded to the cent", value: "28500.3333333333", want: 2850033},
+		{name: "garbage", value: "n/a", wantErr: true},
+		{name: "unsupported type", value: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := got.Scan(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Scan(%v) = %d, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v) error = %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %d cents, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAdjustedBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		percent float64
+		want    Money
+	}{
+		{name: "raise", money: 2500000, percent: 10, want: 2750000},
+		{name: "cut", money: 2500000, percent: -20, want: 2000000},
+		{name: "no change", money: 1999999, percent: 0, want: 1999999},
+		{name: "rounds half up", money: 1, percent: 50, want: 2},
+		{name: "rounds down below half", money: 1001, percent: 0.04, want: 1001},
+		{name: "exact where floats drift", money: 50, percent: 15, want: 58},
+		{name: "negative rounds half away from zero", money: -1, percent: 50, want: -2},
+		{name: "fractional percent", money: 1000000, percent: 3.333, want: 1033330},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.AdjustedBy(tt.percent); got != tt.want {
+				t.Errorf("%s.AdjustedBy(%g) = %s, want %s", tt.money, tt.percent, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+// PriceUpdateRequest represents the request payload for changing only a car's price
+type PriceUpdateRequest struct {
+	ManufacturingValue Money `json:"manufacturing_value" binding:"required" swaggertype:"number"`
+}
+
+// PriceAdjustmentRequest represents a percentage price change applied to all cars of a brand
+type PriceAdjustmentRequest struct {
+	Brand   string  `json:"brand" binding:"required"`
+	Percent float64 `json:"percent" binding:"required,gt=-100"`
+}
+
+// BrandPriceUpdateRequest represents a percentage price change applied to all active cars of a brand
+type BrandPriceUpdateRequest struct {
+	PercentChange float64 `json:"percentChange" binding:"required,gt=-100"`
+}
+
+// BrandPriceUpdateResponse reports the cars whose price a brand price update changed
+type BrandPriceUpdateResponse struct {
+	Brand         string  `json:"brand"`
+	PercentChange float64 `json:"percentChange"`
+	Updated       int     `json:"updated"`
+	IDs           []int64 `json:"ids"`
+}
+
+// PriceAdjustmentPreviewItem shows the effect of a price adjustment on a single car
+type PriceAdjustmentPreviewItem struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	CurrentPrice  Money  `json:"current_price" swaggertype:"number"`
+	ProposedPrice Money  `json:"proposed_price" swaggertype:"number"`
+	BelowMin      bool   `json:"below_min"`
+	ExceedsMax    bool   `json:"exceeds_max"`
+}
+
+// PriceAdjustmentPreviewResponse represents one page of a price adjustment preview
+type PriceAdjustmentPreviewResponse struct {
+	Brand    string                        `json:"brand"`
+	Percent  float64                       `json:"percent"`
+	Page     int                           `json:"page"`
+	PageSize int                           `json:"page_size"`
+	Cars     []*PriceAdjustmentPreviewItem `json:"cars"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CatalogSummary holds aggregate figures about the active catalog
+type CatalogSummary struct {
+	TotalCars       int64
+	TotalBrands     int64
+	TotalValue      Money
+	OldestCreatedAt sql.NullTime
+	NewestCreatedAt sql.NullTime
+}
+
+// CatalogSummaryResponse represents the response payload for the catalog summary
+type CatalogSummaryResponse struct {
+	TotalCars       int64   `json:"total_cars"`
+	TotalBrands     int64   `json:"total_brands"`
+	TotalValue      Money   `json:"total_value" swaggertype:"number"`
+	OldestCreatedAt *string `json:"oldest_created_at"`
+	NewestCreatedAt *string `json:"newest_created_at"`
+}
+
+// ToResponse converts a CatalogSummary to a CatalogSummaryResponse
+func (s *CatalogSummary) ToResponse() *CatalogSummaryResponse {
+	return &CatalogSummaryResponse{
+		TotalCars:       s.TotalCars,
+		TotalBrands:     s.TotalBrands,
+		TotalValue:      s.TotalValue,
+		OldestCreatedAt: formatNullTime(s.OldestCreatedAt),
+		NewestCreatedAt: formatNullTime(s.NewestCreatedAt),
+	}
+}
+
+// CarCountResponse represents the number of active cars
+type CarCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// BrandCountResponse represents the number of active cars for a brand
+type BrandCountResponse struct {
+	Brand string `json:"brand"`
+	Count int64  `json:"count"`
+}
+
+// BrandValueResponse represents the number and summed value of active cars for a brand
+type BrandValueResponse struct {
+	Brand      string `json:"brand"`
+	Count      int64  `json:"count"`
+	TotalValue Money  `json:"total_value" swaggertype:"number"`
+}
+
+// BrandStatsResponse represents price statistics of the active cars for a brand
+type BrandStatsResponse struct {
+	Brand    string `json:"brand"`
+	Count    int64  `json:"count"`
+	AvgPrice Money  `json:"avgPrice" swaggertype:"number"`
+	MinPrice Money  `json:"minPrice" swaggertype:"number"`
+	MaxPrice Money  `json:"maxPrice" swaggertype:"number"`
+}
+
+// formatNullTime formats a nullable time as RFC3339, returning nil when it is not set
+func formatNullTime(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
+	}
+
+	formatted := t.Time.Format(time.RFC3339)
+	return &formatted
+}
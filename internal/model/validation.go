@@ -0,0 +1,45 @@
+package model
+
+// FieldValidationRequest represents the payload for validating a single car field
+type FieldValidationRequest struct {
+	Field string      `json:"field" binding:"required"`
+	Value interface{} `json:"value"`
+}
+
+// BatchValidationError describes why an entry of a bulk request is invalid
+type BatchValidationError struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// BatchValidationResponse represents the outcome of validating a bulk request without saving it
+type BatchValidationResponse struct {
+	Valid  bool                   `json:"valid"`
+	Errors []BatchValidationError `json:"errors"`
+}
+
+// FieldValidationResponse represents the outcome of validating a single car field
+type FieldValidationResponse struct {
+	Field   string `json:"field"`
+	Valid   bool   `json:"valid"`
+	Message string `json:"message,omitempty"`
+}
+
+// FieldSchema describes a car payload field and the rules it is validated
+// against. Bounds and lengths are left out for fields without them.
+type FieldSchema struct {
+	Name             string   `json:"name"`
+	Type             string   `json:"type"`
+	Required         bool     `json:"required"`
+	MaxLength        *int     `json:"maxLength,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	Description      string   `json:"description,omitempty"`
+}
+
+// CarSchemaResponse describes the fields of a car payload, in payload order
+type CarSchemaResponse struct {
+	Fields []FieldSchema `json:"fields"`
+}
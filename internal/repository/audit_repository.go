@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// AuditRepository defines the interface for audit log data operations
+type AuditRepository interface {
+	Record(ctx context.Context, event *model.AuditEvent) error
+	List(ctx context.Context, filter model.AuditEventFilter) ([]*model.AuditEvent, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new instance of AuditRepository
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Record inserts an audit event. The time is set here rather than by the column
+// default, whose text SQLite can't compare with the bound times of List.
+func (r *auditRepository) Record(ctx context.Context, event *model.AuditEvent) error {
+	query := `
+		INSERT INTO audit_log (car_id, event_type, actor, details, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	now := time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query, event.CarID, event.EventType, event.Actor, event.Details, now).
+		Scan(&event.ID)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, event.CarID, event.EventType, event.Actor, event.Details, now)
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	event.CreatedAt = now
+
+	return nil
+}
+
+// List retrieves audit events in chronological order using keyset pagination on the event ID
+func (r *auditRepository) List(ctx context.Context, filter model.AuditEventFilter) ([]*model.AuditEvent, error) {
+	conditions := []string{"id > $1"}
+	args := []interface{}{filter.AfterID}
+
+	if filter.CarID != 0 {
+		args = append(args, filter.CarID)
+		conditions = append(conditions, fmt.Sprintf("car_id = $%d", len(args)))
+	}
+
+	if filter.EventType != "" {
+		args = append(args, filter.EventType)
+		conditions = append(conditions, fmt.Sprintf("event_type = $%d", len(args)))
+	}
+
+	// Event times are stored in UTC, which SQLite compares as text
+	if filter.Since != nil {
+		args = append(args, filter.Since.UTC())
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := `
+		SELECT id, car_id, event_type, actor, details, created_at
+		FROM audit_log
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY id
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, args...)
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.AuditEvent
+	for rows.Next() {
+		var event model.AuditEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.CarID,
+			&event.EventType,
+			&event.Actor,
+			&event.Details,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event row: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit event rows: %w", err)
+	}
+
+	return events, nil
+}
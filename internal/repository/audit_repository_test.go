@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+)
+
+func TestAuditListSQLite(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewAuditRepository(db)
+
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	seed := []struct {
+		carID     int64
+		eventType string
+		at        time.Time
+	}{
+		{1, "car.created", base},
+		{2, "car.created", base.Add(time.Hour)},
+		{1, "car.updated", base.Add(2 * time.Hour)},
+		{1, "car.deleted", base.Add(3 * time.Hour)},
+		{2, "car.updated", base.Add(4 * time.Hour)},
+	}
+	ids := make([]int64, 0, len(seed))
+	for _, e := range seed {
+		event := &model.AuditEvent{CarID: e.carID, EventType: e.eventType, Actor: sql.NullString{String: "alice", Valid: true}}
+		if err := repo.Record(ctx, event); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		if _, err := db.Exec(`UPDATE audit_log SET created_at = $1 WHERE id = $2`, e.at, event.ID); err != nil {
+			t.Fatalf("failed to backdate event: %v", err)
+		}
+		ids = append(ids, event.ID)
+	}
+
+	since := base.Add(2 * time.Hour)
+	sinceLocal := since.In(time.FixedZone("UTC+2", 2*60*60))
+
+	tests := []struct {
+		name   string
+		filter model.AuditEventFilter
+		want   []int64
+	}{
+		{name: "all", filter: model.AuditEventFilter{Limit: 10}, want: ids},
+		{name: "by type", filter: model.AuditEventFilter{EventType: "car.updated", Limit: 10}, want: []int64{ids[2], ids[4]}},
+		{name: "by car", filter: model.AuditEventFilter{CarID: 2, Limit: 10}, want: []int64{ids[1], ids[4]}},
+		{name: "since, inclusive", filter: model.AuditEventFilter{Since: &since, Limit: 10}, want: []int64{ids[2], ids[3], ids[4]}},
+		{name: "since in another time zone", filter: model.AuditEventFilter{Since: &sinceLocal, Limit: 10}, want: []int64{ids[2], ids[3], ids[4]}},
+		{name: "type and since", filter: model.AuditEventFilter{EventType: "car.created", Since: &since, Limit: 10}, want: nil},
+		{name: "first page", filter: model.AuditEventFilter{Limit: 2}, want: ids[:2]},
+		{name: "after a cursor", filter: model.AuditEventFilter{AfterID: ids[1], Limit: 2}, want: ids[2:4]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			var got []int64
+			for _, event := range events {
+				got = append(got, event.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("List(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
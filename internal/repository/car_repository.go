@@ -3,203 +3,546 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/username/go-car-service/internal/errs"
 	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/pkg/database"
 	"github.com/username/go-car-service/pkg/logger"
 )
 
 // CarRepository defines the interface for car data operations
 type CarRepository interface {
 	Create(ctx context.Context, car *model.Car) (int64, error)
-	GetByID(ctx context.Context, id int64) (*model.Car, error)
-	GetByName(ctx context.Context, name string) (*model.Car, error)
+	CreateBatch(ctx context.Context, cars []*model.Car) ([]int64, error)
+	GetByID(ctx context.Context, id int64, includeDeleted bool) (*model.Car, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]*model.Car, error)
+	Exists(ctx context.Context, id int64) (bool, error)
+	GetByName(ctx context.Context, name string, includeDeleted bool) (*model.Car, error)
+	GetByNameAndBrand(ctx context.Context, name, brand string, includeDeleted bool) (*model.Car, error)
+	ReserveIdempotencyKey(ctx context.Context, client, key string, expiredBefore time.Time) (bool, error)
+	GetIdempotentResponse(ctx context.Context, client, key string, expiredBefore time.Time) ([]byte, error)
+	SaveIdempotentResponse(ctx context.Context, client, key string, carID int64, response []byte) error
+	PurgeIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error)
 	GetByBrand(ctx context.Context, brand string) ([]*model.Car, error)
+	CountByBrand(ctx context.Context, brand string) (int64, error)
+	GetByColor(ctx context.Context, color string) ([]*model.Car, error)
+	GetByCreatedDate(ctx context.Context, date time.Time) ([]*model.Car, error)
+	GetChangedSince(ctx context.Context, after model.ChangeCursor, limit int) ([]*model.Car, error)
 	GetByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*model.Car, error)
+	GetByYearRange(ctx context.Context, startYear, finalYear int) ([]*model.Car, error)
 	GetAll(ctx context.Context, page, pageSize int) ([]*model.Car, error)
+	Count(ctx context.Context) (int64, error)
+	GetAfter(ctx context.Context, afterID int64, limit int) ([]*model.Car, error)
+	GetRecent(ctx context.Context, limit int) ([]*model.Car, error)
+	GetRelated(ctx context.Context, car *model.Car, limit int) ([]*model.Car, error)
+	GetCheapest(ctx context.Context) (*model.Car, error)
+	GetMostExpensive(ctx context.Context) (*model.Car, error)
+	GetAllDeleted(ctx context.Context, page, pageSize int) ([]*model.Car, error)
+	Filter(ctx context.Context, filter model.CarFilter) ([]*model.Car, error)
+	Stream(ctx context.Context, brand string, fn func(car *model.Car) error) error
+	GetRandomFeatured(ctx context.Context) (*model.Car, error)
+	Search(ctx context.Context, query string, page, pageSize, maxResults int) ([]*model.Car, bool, error)
+	GetSummary(ctx context.Context) (*model.CatalogSummary, error)
+	GetBrandsByValue(ctx context.Context, limit int) ([]*model.BrandValueResponse, error)
+	ListBrands(ctx context.Context) ([]string, error)
+	StatsByBrand(ctx context.Context, page, pageSize int) ([]*model.BrandStatsResponse, error)
 	Update(ctx context.Context, car *model.Car) error
+	UpdatePrice(ctx context.Context, id int64, value model.Money) error
+	AdjustBrandPrices(ctx context.Context, brand string, percent float64, minValue, maxValue model.Money) ([]int64, error)
 	Delete(ctx context.Context, id int64) error
+	DeleteBatch(ctx context.Context, ids []int64) ([]int64, error)
+	Merge(ctx context.Context, primaryID, duplicateID int64, actor sql.NullString) error
+	ScheduleDelete(ctx context.Context, id int64, at time.Time) error
+	CancelScheduledDelete(ctx context.Context, id int64) error
+	DeleteScheduled(ctx context.Context, now time.Time) ([]int64, error)
+	PurgeDeletedBefore(ctx context.Context, before time.Time) ([]int64, error)
+}
+
+// IdempotencyKeyTTL is how long an idempotency key keeps replaying the response of the request that used it
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// carColumns lists the columns selected for a car, in the order scanCar expects.
+// A missing year is read as 0.
+const carColumns = `id, name, brand, manufacturing_value, description, color, COALESCE(year, 0), featured, weight, delete_scheduled_at, deleted_at, version, created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
 type carRepository struct {
-	db *sql.DB
+	// db runs the statements, on the pool or in the repository's transaction
+	db DBTX
+	// pool begins transactions; it is nil when the repository is bound to one
+	pool      *sql.DB
+	driver    string
+	slowQuery time.Duration
+}
+
+// NewCarRepository creates a new instance of CarRepository for a database
+// opened with the given driver. Statements taking longer than slowQuery are
+// logged; a non-positive slowQuery disables this.
+func NewCarRepository(db *sql.DB, driver string, slowQuery time.Duration) CarRepository {
+	return &carRepository{db: withSlowQueryLog(db, slowQuery), pool: db, driver: driver, slowQuery: slowQuery}
+}
+
+// newTxCarRepository creates a CarRepository running every statement in tx
+func newTxCarRepository(tx *sql.Tx, driver string, slowQuery time.Duration) CarRepository {
+	return &carRepository{db: withSlowQueryLog(tx, slowQuery), driver: driver, slowQuery: slowQuery}
 }
 
-// NewCarRepository creates a new instance of CarRepository
-func NewCarRepository(db *sql.DB) CarRepository {
-	return &carRepository{db: db}
+// inTx runs fn in a transaction of its own, or in the repository's transaction
+// when it has one, leaving the commit to whoever began it
+func (r *carRepository) inTx(ctx context.Context, fn func(tx DBTX) error) error {
+	if r.pool == nil {
+		return fn(r.db)
+	}
+
+	return runInTx(ctx, r.pool, nil, func(tx *sql.Tx) error {
+		return fn(withSlowQueryLog(tx, r.slowQuery))
+	})
 }
 
 // Create creates a new car in the database
 func (r *carRepository) Create(ctx context.Context, car *model.Car) (int64, error) {
 	query := `
-		INSERT INTO cars (name, brand, manufacturing_value, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id
+		INSERT INTO cars (name, brand, manufacturing_value, description, color, year, featured, weight, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8, $9, $10)
 	`
 
 	now := time.Now()
 	car.CreatedAt = now
 	car.UpdatedAt = now
 
-	var id int64
-	err := r.db.QueryRowContext(
+	id, err := insertID(
 		ctx,
+		r.db,
+		r.driver,
 		query,
 		car.Name,
 		car.Brand,
 		car.ManufacturingValue,
 		car.Description,
+		car.Color,
+		car.Year,
+		car.Featured,
+		car.Weight,
 		car.CreatedAt,
 		car.UpdatedAt,
-	).Scan(&id)
+	)
 
 	if err != nil {
-		logger.LogSQLError(err, query, car.Name, car.Brand, car.ManufacturingValue, car.Description, now, now)
-		return 0, fmt.Errorf("failed to create car: %v", err)
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("car with name %s: %w", car.Name, errs.ErrDuplicateCar)
+		}
+		logger.LogSQLError(ctx, err, query, car.Name, car.Brand, car.ManufacturingValue, car.Description, car.Color, car.Year, car.Featured, car.Weight, now, now)
+		return 0, fmt.Errorf("failed to create car: %w", err)
 	}
 
 	return id, nil
 }
 
-// GetByID retrieves a car by its ID
-func (r *carRepository) GetByID(ctx context.Context, id int64) (*model.Car, error) {
+// CreateBatch creates several cars inside a single transaction, so either all
+// of them are inserted or none are
+func (r *carRepository) CreateBatch(ctx context.Context, cars []*model.Car) ([]int64, error) {
+	query := `
+		INSERT INTO cars (name, brand, manufacturing_value, description, color, year, featured, weight, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8, $9, $10)
+	`
+
+	var ids []int64
+	err := r.inTx(ctx, func(tx DBTX) error {
+		now := time.Now()
+		ids = make([]int64, 0, len(cars))
+		for _, car := range cars {
+			car.CreatedAt = now
+			car.UpdatedAt = now
+
+			id, err := insertID(
+				ctx,
+				tx,
+				r.driver,
+				query,
+				car.Name,
+				car.Brand,
+				car.ManufacturingValue,
+				car.Description,
+				car.Color,
+				car.Year,
+				car.Featured,
+				car.Weight,
+				car.CreatedAt,
+				car.UpdatedAt,
+			)
+			if err != nil {
+				if isUniqueViolation(err) {
+					return fmt.Errorf("car with name %s: %w", car.Name, errs.ErrDuplicateCar)
+				}
+				logger.LogSQLError(ctx, err, query, car.Name, car.Brand, car.ManufacturingValue, car.Description, car.Color, car.Year, car.Featured, car.Weight, now, now)
+				return fmt.Errorf("failed to create car %s: %w", car.Name, err)
+			}
+			car.ID = id
+			ids = append(ids, id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetByID retrieves an active car by its ID, or also a soft-deleted one with includeDeleted
+func (r *carRepository) GetByID(ctx context.Context, id int64, includeDeleted bool) (*model.Car, error) {
 	query := `
-		SELECT id, name, brand, manufacturing_value, description, created_at, updated_at
+		SELECT ` + carColumns + `
 		FROM cars
 		WHERE id = $1 AND deleted_at IS NULL
 	`
+	if includeDeleted {
+		query = `
+			SELECT ` + carColumns + `
+			FROM cars
+			WHERE id = $1
+		`
+	}
 
-	var car model.Car
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&car.ID,
-		&car.Name,
-		&car.Brand,
-		&car.ManufacturingValue,
-		&car.Description,
-		&car.CreatedAt,
-		&car.UpdatedAt,
-	)
-
+	car, err := scanCar(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("car with ID %d not found", id)
+			return nil, fmt.Errorf("car with ID %d: %w", id, errs.ErrCarNotFound)
 		}
-		logger.LogSQLError(err, query, id)
-		return nil, fmt.Errorf("failed to get car: %v", err)
+		logger.LogSQLError(ctx, err, query, id)
+		return nil, fmt.Errorf("failed to get car: %w", err)
 	}
 
-	return &car, nil
+	return car, nil
+}
+
+// Exists reports whether there is an active car with the given ID, without fetching it
+func (r *carRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM cars WHERE id = $1 AND deleted_at IS NULL)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		logger.LogSQLError(ctx, err, query, id)
+		return false, fmt.Errorf("failed to check car existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetByIDs retrieves the active cars among ids, in no particular order
+func (r *carRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Car, error) {
+	condition, args := idInCondition(r.driver, ids, 1)
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE ` + condition + ` AND deleted_at IS NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, args...)
+		return nil, fmt.Errorf("failed to get cars by IDs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCars(rows)
 }
 
-// GetByName retrieves a car by its name
-func (r *carRepository) GetByName(ctx context.Context, name string) (*model.Car, error) {
+// GetByName retrieves the active car with a name. With includeDeleted, the most
+// recently deleted car with the name is returned when no active car has it;
+// names are only unique among active cars.
+func (r *carRepository) GetByName(ctx context.Context, name string, includeDeleted bool) (*model.Car, error) {
 	query := `
-		SELECT id, name, brand, manufacturing_value, description, created_at, updated_at
+		SELECT ` + carColumns + `
 		FROM cars
 		WHERE name = $1 AND deleted_at IS NULL
 	`
+	if includeDeleted {
+		query = `
+			SELECT ` + carColumns + `
+			FROM cars
+			WHERE name = $1
+			ORDER BY deleted_at IS NOT NULL, deleted_at DESC
+			LIMIT 1
+		`
+	}
 
-	var car model.Car
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
-		&car.ID,
-		&car.Name,
-		&car.Brand,
-		&car.ManufacturingValue,
-		&car.Description,
-		&car.CreatedAt,
-		&car.UpdatedAt,
-	)
+	car, err := scanCar(r.db.QueryRowContext(ctx, query, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("car with name %s: %w", name, errs.ErrCarNotFound)
+		}
+		logger.LogSQLError(ctx, err, query, name)
+		return nil, fmt.Errorf("failed to get car by name: %w", err)
+	}
+
+	return car, nil
+}
+
+// GetByNameAndBrand is GetByName for a name and brand pair, for when names are
+// only unique per brand. Brands are matched case-insensitively.
+func (r *carRepository) GetByNameAndBrand(ctx context.Context, name, brand string, includeDeleted bool) (*model.Car, error) {
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE name = $1 AND LOWER(brand) = LOWER($2) AND deleted_at IS NULL
+	`
+	if includeDeleted {
+		query = `
+			SELECT ` + carColumns + `
+			FROM cars
+			WHERE name = $1 AND LOWER(brand) = LOWER($2)
+			ORDER BY deleted_at IS NOT NULL, deleted_at DESC
+			LIMIT 1
+		`
+	}
 
+	car, err := scanCar(r.db.QueryRowContext(ctx, query, name, brand))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("car with name %s not found", name)
+			return nil, fmt.Errorf("car with name %s and brand %s: %w", name, brand, errs.ErrCarNotFound)
 		}
-		logger.LogSQLError(err, query, name)
-		return nil, fmt.Errorf("failed to get car by name: %v", err)
+		logger.LogSQLError(ctx, err, query, name, brand)
+		return nil, fmt.Errorf("failed to get car by name and brand: %w", err)
 	}
 
-	return &car, nil
+	return car, nil
+}
+
+// ReserveIdempotencyKey claims key for client ahead of the request using it,
+// reporting false when the client already used it after expiredBefore. An expired
+// key is claimed afresh. Inside a transaction, a concurrent claim of the same key
+// waits until the transaction holding it ends.
+func (r *carRepository) ReserveIdempotencyKey(ctx context.Context, client, key string, expiredBefore time.Time) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (client, key, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (client, key) DO UPDATE
+		SET car_id = NULL, response = NULL, created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.created_at <= $4
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, client, key, now, expiredBefore)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, client, key, now, expiredBefore)
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetIdempotentResponse retrieves the response stored for a key the client used
+// after expiredBefore
+func (r *carRepository) GetIdempotentResponse(ctx context.Context, client, key string, expiredBefore time.Time) ([]byte, error) {
+	query := `
+		SELECT response
+		FROM idempotency_keys
+		WHERE client = $1 AND key = $2 AND created_at > $3 AND response IS NOT NULL
+	`
+
+	var response string
+	if err := r.db.QueryRowContext(ctx, query, client, key, expiredBefore).Scan(&response); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("response for idempotency key %s: %w", key, errs.ErrCarNotFound)
+		}
+		logger.LogSQLError(ctx, err, query, client, key, expiredBefore)
+		return nil, fmt.Errorf("failed to get idempotent response: %w", err)
+	}
+
+	return []byte(response), nil
+}
+
+// SaveIdempotentResponse stores the response of the request that reserved key,
+// along with the car it created
+func (r *carRepository) SaveIdempotentResponse(ctx context.Context, client, key string, carID int64, response []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET car_id = $1, response = $2
+		WHERE client = $3 AND key = $4
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, carID, string(response), client, key); err != nil {
+		logger.LogSQLError(ctx, err, query, carID, string(response), client, key)
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeIdempotencyKeys removes the keys used before expiredBefore, returning how
+// many were removed
+func (r *carRepository) PurgeIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE created_at <= $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, expiredBefore)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, expiredBefore)
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rowsAffected, nil
 }
 
-// GetByBrand retrieves all cars by brand
+// GetByBrand retrieves all cars by brand, matched case-insensitively
 func (r *carRepository) GetByBrand(ctx context.Context, brand string) ([]*model.Car, error) {
 	query := `
-		SELECT id, name, brand, manufacturing_value, description, created_at, updated_at
+		SELECT ` + carColumns + `
 		FROM cars
-		WHERE brand = $1 AND deleted_at IS NULL
+		WHERE LOWER(brand) = LOWER($1) AND deleted_at IS NULL
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, brand)
 	if err != nil {
-		logger.LogSQLError(err, query, brand)
-		return nil, fmt.Errorf("failed to get cars by brand: %v", err)
+		logger.LogSQLError(ctx, err, query, brand)
+		return nil, fmt.Errorf("failed to get cars by brand: %w", err)
 	}
 	defer rows.Close()
 
-	var cars []*model.Car
-	for rows.Next() {
-		var car model.Car
-		if err := rows.Scan(
-			&car.ID,
-			&car.Name,
-			&car.Brand,
-			&car.ManufacturingValue,
-			&car.Description,
-			&car.CreatedAt,
-			&car.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan car row: %v", err)
-		}
-		cars = append(cars, &car)
+	return scanCars(rows)
+}
+
+// GetByColor retrieves the active cars of a color, matched case-insensitively
+func (r *carRepository) GetByColor(ctx context.Context, color string) ([]*model.Car, error) {
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE LOWER(color) = LOWER($1) AND deleted_at IS NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, color)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, color)
+		return nil, fmt.Errorf("failed to get cars by color: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCars(rows)
+}
+
+// GetByCreatedDate retrieves the active cars created on the calendar day of date,
+// in date's time zone, oldest first
+func (r *carRepository) GetByCreatedDate(ctx context.Context, date time.Time) ([]*model.Car, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.AddDate(0, 0, 1)
+
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE created_at >= $1 AND created_at < $2 AND deleted_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, start, end)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, start, end)
+		return nil, fmt.Errorf("failed to get cars by creation date: %w", err)
 	}
+	defer rows.Close()
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating car rows: %v", err)
+	return scanCars(rows)
+}
+
+// GetChangedSince retrieves up to limit cars updated or soft deleted after the
+// cursor, including the deleted ones, in the order they last changed
+func (r *carRepository) GetChangedSince(ctx context.Context, after model.ChangeCursor, limit int) ([]*model.Car, error) {
+	changedAt := "CASE WHEN deleted_at > updated_at THEN deleted_at ELSE updated_at END"
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE (updated_at >= $1 OR deleted_at >= $1)
+			AND (` + changedAt + ` > $1 OR (` + changedAt + ` = $1 AND id > $2))
+		ORDER BY ` + changedAt + `, id
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, after.ChangedAt, after.ID, limit)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, after.ChangedAt, after.ID, limit)
+		return nil, fmt.Errorf("failed to get changed cars: %w", err)
 	}
+	defer rows.Close()
 
-	return cars, nil
+	return scanCars(rows)
+}
+
+// CountByBrand counts the active cars of a brand, matched case-insensitively
+func (r *carRepository) CountByBrand(ctx context.Context, brand string) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM cars
+		WHERE LOWER(brand) = LOWER($1) AND deleted_at IS NULL
+	`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, brand).Scan(&count); err != nil {
+		logger.LogSQLError(ctx, err, query, brand)
+		return 0, fmt.Errorf("failed to count cars by brand: %w", err)
+	}
+
+	return count, nil
 }
 
 // GetByPriceRange retrieves all cars within a price range
 func (r *carRepository) GetByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*model.Car, error) {
 	query := `
-		SELECT id, name, brand, manufacturing_value, description, created_at, updated_at
+		SELECT ` + carColumns + `
 		FROM cars
 		WHERE manufacturing_value BETWEEN $1 AND $2 AND deleted_at IS NULL
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, minPrice, maxPrice)
 	if err != nil {
-		logger.LogSQLError(err, query, minPrice, maxPrice)
-		return nil, fmt.Errorf("failed to get cars by price range: %v", err)
+		logger.LogSQLError(ctx, err, query, minPrice, maxPrice)
+		return nil, fmt.Errorf("failed to get cars by price range: %w", err)
 	}
 	defer rows.Close()
 
-	var cars []*model.Car
-	for rows.Next() {
-		var car model.Car
-		if err := rows.Scan(
-			&car.ID,
-			&car.Name,
-			&car.Brand,
-			&car.ManufacturingValue,
-			&car.Description,
-			&car.CreatedAt,
-			&car.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan car row: %v", err)
-		}
-		cars = append(cars, &car)
-	}
+	return scanCars(rows)
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating car rows: %v", err)
+// GetByYearRange retrieves all cars whose model year is within a range
+func (r *carRepository) GetByYearRange(ctx context.Context, startYear, finalYear int) ([]*model.Car, error) {
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE year BETWEEN $1 AND $2 AND deleted_at IS NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, startYear, finalYear)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, startYear, finalYear)
+		return nil, fmt.Errorf("failed to get cars by year range: %w", err)
 	}
+	defer rows.Close()
 
-	return cars, nil
+	return scanCars(rows)
 }
 
 // GetAll retrieves all cars with pagination
@@ -207,7 +550,7 @@ func (r *carRepository) GetAll(ctx context.Context, page, pageSize int) ([]*mode
 	offset := (page - 1) * pageSize
 
 	query := `
-		SELECT id, name, brand, manufacturing_value, description, created_at, updated_at
+		SELECT ` + carColumns + `
 		FROM cars
 		WHERE deleted_at IS NULL
 		ORDER BY id
@@ -216,95 +559,965 @@ func (r *carRepository) GetAll(ctx context.Context, page, pageSize int) ([]*mode
 
 	rows, err := r.db.QueryContext(ctx, query, pageSize, offset)
 	if err != nil {
-		logger.LogSQLError(err, query, pageSize, offset)
-		return nil, fmt.Errorf("failed to get all cars: %v", err)
+		logger.LogSQLError(ctx, err, query, pageSize, offset)
+		return nil, fmt.Errorf("failed to get all cars: %w", err)
 	}
 	defer rows.Close()
 
-	var cars []*model.Car
-	for rows.Next() {
-		var car model.Car
-		if err := rows.Scan(
-			&car.ID,
-			&car.Name,
-			&car.Brand,
-			&car.ManufacturingValue,
-			&car.Description,
-			&car.CreatedAt,
-			&car.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan car row: %v", err)
-		}
-		cars = append(cars, &car)
-	}
+	return scanCars(rows)
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating car rows: %v", err)
+// Count counts the active cars
+func (r *carRepository) Count(ctx context.Context) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM cars
+		WHERE deleted_at IS NULL
+	`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		logger.LogSQLError(ctx, err, query)
+		return 0, fmt.Errorf("failed to count cars: %w", err)
 	}
 
-	return cars, nil
+	return count, nil
 }
 
-// Update updates an existing car
-func (r *carRepository) Update(ctx context.Context, car *model.Car) error {
+// GetAfter retrieves up to limit active cars with an ID above afterID, in ID
+// order. Unlike GetAll's OFFSET, the cost doesn't grow with the depth of the page.
+func (r *carRepository) GetAfter(ctx context.Context, afterID int64, limit int) ([]*model.Car, error) {
 	query := `
-		UPDATE cars
-		SET name = $1, brand = $2, manufacturing_value = $3, description = $4, updated_at = $5
-		WHERE id = $6 AND deleted_at IS NULL
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE id > $1 AND deleted_at IS NULL
+		ORDER BY id
+		LIMIT $2
 	`
 
-	car.UpdatedAt = time.Now()
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, afterID, limit)
+		return nil, fmt.Errorf("failed to get cars after ID %d: %w", afterID, err)
+	}
+	defer rows.Close()
 
-	result, err := r.db.ExecContext(
-		ctx,
-		query,
-		car.Name,
-		car.Brand,
-		car.ManufacturingValue,
-		car.Description,
-		car.UpdatedAt,
-		car.ID,
-	)
+	return scanCars(rows)
+}
+
+// GetRecent retrieves the most recently created active cars, newest first
+func (r *carRepository) GetRecent(ctx context.Context, limit int) ([]*model.Car, error) {
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`
 
+	rows, err := r.db.QueryContext(ctx, query, limit)
 	if err != nil {
-		logger.LogSQLError(err, query, car.Name, car.Brand, car.ManufacturingValue, car.Description, car.UpdatedAt, car.ID)
-		return fmt.Errorf("failed to update car: %v", err)
+		logger.LogSQLError(ctx, err, query, limit)
+		return nil, fmt.Errorf("failed to get recent cars: %w", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
+	return scanCars(rows)
+}
+
+// GetRelated retrieves up to limit other active cars of car's brand, those
+// closest in price to car first
+func (r *carRepository) GetRelated(ctx context.Context, car *model.Car, limit int) ([]*model.Car, error) {
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE LOWER(brand) = LOWER($1) AND id <> $2 AND deleted_at IS NULL
+		ORDER BY ABS(manufacturing_value - $3), id
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, car.Brand, car.ID, car.ManufacturingValue, limit)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		logger.LogSQLError(ctx, err, query, car.Brand, car.ID, car.ManufacturingValue, limit)
+		return nil, fmt.Errorf("failed to get related cars: %w", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("car with ID %d not found", car.ID)
+	return scanCars(rows)
+}
+
+// GetCheapest retrieves the active car with the lowest manufacturing value
+func (r *carRepository) GetCheapest(ctx context.Context) (*model.Car, error) {
+	return r.getPriceExtreme(ctx, "ASC")
+}
+
+// GetMostExpensive retrieves the active car with the highest manufacturing value
+func (r *carRepository) GetMostExpensive(ctx context.Context) (*model.Car, error) {
+	return r.getPriceExtreme(ctx, "DESC")
+}
+
+// getPriceExtreme retrieves the first active car ordered by manufacturing value in
+// the given direction. Ties go to the oldest car.
+func (r *carRepository) getPriceExtreme(ctx context.Context, direction string) (*model.Car, error) {
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE deleted_at IS NULL
+		ORDER BY manufacturing_value ` + direction + `, id
+		LIMIT 1
+	`
+
+	car, err := scanCar(r.db.QueryRowContext(ctx, query))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no cars: %w", errs.ErrCarNotFound)
+		}
+		logger.LogSQLError(ctx, err, query)
+		return nil, fmt.Errorf("failed to get car by price: %w", err)
 	}
 
-	return nil
+	return car, nil
 }
 
-// Delete soft deletes a car by ID
-func (r *carRepository) Delete(ctx context.Context, id int64) error {
+// GetAllDeleted retrieves soft-deleted cars with pagination, most recently deleted first
+func (r *carRepository) GetAllDeleted(ctx context.Context, page, pageSize int) ([]*model.Car, error) {
+	offset := (page - 1) * pageSize
+
 	query := `
-		UPDATE cars
-		SET deleted_at = $1
-		WHERE id = $2 AND deleted_at IS NULL
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+		LIMIT $1 OFFSET $2
 	`
 
-	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	rows, err := r.db.QueryContext(ctx, query, pageSize, offset)
 	if err != nil {
-		logger.LogSQLError(err, query, id)
-		return fmt.Errorf("failed to delete car: %v", err)
+		logger.LogSQLError(ctx, err, query, pageSize, offset)
+		return nil, fmt.Errorf("failed to get deleted cars: %w", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
+	return scanCars(rows)
+}
+
+// Filter retrieves cars matching all of the criteria set in the filter, with pagination
+func (r *carRepository) Filter(ctx context.Context, filter model.CarFilter) ([]*model.Car, error) {
+	conditions, args := filterConditions(filter)
+
+	args = append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY id
+		LIMIT $` + fmt.Sprint(len(args)-1) + ` OFFSET $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		logger.LogSQLError(ctx, err, query, args...)
+		return nil, fmt.Errorf("failed to filter cars: %w", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("car with ID %d not found", id)
+	return scanCars(rows)
+}
+
+// Stream calls fn for every active car in ID order, one row at a time, without
+// loading the whole result into memory. When brand is set only that brand's cars
+// are streamed, matched case-insensitively. Streaming stops at the first error from fn.
+// On Postgres the statement timeout is lifted for the stream, which is bounded by ctx instead.
+func (r *carRepository) Stream(ctx context.Context, brand string, fn func(car *model.Car) error) error {
+	if r.pool == nil || r.driver != database.DriverPostgres {
+		return r.stream(ctx, r.db, brand, fn)
 	}
 
-	return nil
+	// Not retried: fn may already have written rows out when a retry would start
+	return runTxOnce(ctx, r.pool, &sql.TxOptions{ReadOnly: true}, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+			return fmt.Errorf("failed to lift statement timeout: %w", err)
+		}
+		return r.stream(ctx, withSlowQueryLog(tx, r.slowQuery), brand, fn)
+	})
+}
+
+// stream runs the Stream query on db
+func (r *carRepository) stream(ctx context.Context, db DBTX, brand string, fn func(car *model.Car) error) error {
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE deleted_at IS NULL AND ($1 = '' OR LOWER(brand) = LOWER($1))
+		ORDER BY id
+	`
+
+	rows, err := db.QueryContext(ctx, query, brand)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, brand)
+		return fmt.Errorf("failed to stream cars: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan car row: %w", err)
+		}
+
+		if err := fn(car); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating car rows: %w", err)
+	}
+
+	return nil
+}
+
+// filterConditions builds the WHERE conditions for a filter and their arguments,
+// numbering the placeholders in the order of the returned arguments
+func filterConditions(filter model.CarFilter) ([]string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.Brand != "" {
+		args = append(args, filter.Brand)
+		conditions = append(conditions, fmt.Sprintf("LOWER(brand) = LOWER($%d)", len(args)))
+	}
+
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("manufacturing_value >= $%d", len(args)))
+	}
+
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("manufacturing_value <= $%d", len(args)))
+	}
+
+	if filter.MinYear != nil {
+		args = append(args, *filter.MinYear)
+		conditions = append(conditions, fmt.Sprintf("year >= $%d", len(args)))
+	}
+
+	if filter.MaxYear != nil {
+		args = append(args, *filter.MaxYear)
+		conditions = append(conditions, fmt.Sprintf("year <= $%d", len(args)))
+	}
+
+	if filter.CreatedAfter != nil {
+		args = append(args, *filter.CreatedAfter)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if filter.CreatedBefore != nil {
+		args = append(args, *filter.CreatedBefore)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	if filter.UpdatedAfter != nil {
+		args = append(args, *filter.UpdatedAfter)
+		conditions = append(conditions, fmt.Sprintf("updated_at >= $%d", len(args)))
+	}
+
+	if filter.UpdatedBefore != nil {
+		args = append(args, *filter.UpdatedBefore)
+		conditions = append(conditions, fmt.Sprintf("updated_at <= $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+// GetRandomFeatured picks one featured car at random, with each car's chance of
+// being picked proportional to its weight
+func (r *carRepository) GetRandomFeatured(ctx context.Context) (*model.Car, error) {
+	// Featured cars are laid out one after the other, each taking as much room as
+	// its weight, and the car found at a uniformly random point is picked. This
+	// needs no math functions, which SQLite lacks.
+	query := `
+		WITH featured AS (
+			SELECT id, SUM(weight) OVER (ORDER BY id) AS cumulative, SUM(weight) OVER () AS total
+			FROM cars
+			WHERE featured AND deleted_at IS NULL
+		)
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE id = (
+			SELECT id FROM featured
+			WHERE cumulative > CAST($1 AS DOUBLE PRECISION) * total
+			ORDER BY cumulative
+			LIMIT 1
+		)
+	`
+	point := rand.Float64()
+
+	car, err := scanCar(r.db.QueryRowContext(ctx, query, point))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no featured car: %w", errs.ErrCarNotFound)
+		}
+		logger.LogSQLError(ctx, err, query, point)
+		return nil, fmt.Errorf("failed to get random featured car: %w", err)
+	}
+
+	return car, nil
+}
+
+// Search retrieves cars whose name, brand or description contains the given
+// text, case-insensitively, with pagination
+func (r *carRepository) Search(ctx context.Context, text string, page, pageSize, maxResults int) ([]*model.Car, bool, error) {
+	offset := (page - 1) * pageSize
+	limit := pageSize
+	if maxResults > 0 {
+		if offset >= maxResults {
+			return []*model.Car{}, true, nil
+		}
+		limit = min(pageSize, maxResults-offset)
+	}
+	pattern := "%" + escapeLike(text) + "%"
+
+	// Fetch one extra row to tell whether more matches lie beyond this page
+	like := caseInsensitiveLike(r.driver)
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars
+		WHERE deleted_at IS NULL
+			AND (name ` + like + ` $1 ESCAPE '\' OR brand ` + like + ` $1 ESCAPE '\' OR description ` + like + ` $1 ESCAPE '\')
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pattern, limit+1, offset)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, pattern, limit+1, offset)
+		return nil, false, fmt.Errorf("failed to search cars: %w", err)
+	}
+	defer rows.Close()
+
+	cars, err := scanCars(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(cars) <= limit {
+		return cars, false, nil
+	}
+
+	// More matches exist; they are only cut off when this page reaches the cap
+	capped := maxResults > 0 && offset+limit >= maxResults
+	return cars[:limit], capped, nil
+}
+
+// GetSummary computes aggregate figures over all active cars
+func (r *carRepository) GetSummary(ctx context.Context) (*model.CatalogSummary, error) {
+	query := `
+		SELECT COUNT(*), COUNT(DISTINCT brand), COALESCE(SUM(manufacturing_value), 0), MIN(created_at), MAX(created_at)
+		FROM cars
+		WHERE deleted_at IS NULL
+	`
+
+	var summary model.CatalogSummary
+	err := r.db.QueryRowContext(ctx, query).Scan(
+		&summary.TotalCars,
+		&summary.TotalBrands,
+		&summary.TotalValue,
+		aggregateTime{&summary.OldestCreatedAt},
+		aggregateTime{&summary.NewestCreatedAt},
+	)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query)
+		return nil, fmt.Errorf("failed to get catalog summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// GetBrandsByValue sums the value of the active cars per brand, ordered by total value
+// descending. A limit of 0 returns every brand.
+func (r *carRepository) GetBrandsByValue(ctx context.Context, limit int) ([]*model.BrandValueResponse, error) {
+	query := `
+		SELECT brand, COUNT(*), SUM(manufacturing_value) AS total_value
+		FROM cars
+		WHERE deleted_at IS NULL
+		GROUP BY brand
+		ORDER BY total_value DESC, brand
+	`
+
+	// SQLite rejects a NULL limit, so leave the clause out when there is none
+	var args []interface{}
+	if limit > 0 {
+		args = append(args, limit)
+		query += `LIMIT $1`
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, args...)
+		return nil, fmt.Errorf("failed to get brands by value: %w", err)
+	}
+	defer rows.Close()
+
+	brands := make([]*model.BrandValueResponse, 0)
+	for rows.Next() {
+		var brand model.BrandValueResponse
+		if err := rows.Scan(&brand.Brand, &brand.Count, &brand.TotalValue); err != nil {
+			return nil, fmt.Errorf("failed to scan brand value row: %w", err)
+		}
+		brands = append(brands, &brand)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating brand value rows: %w", err)
+	}
+
+	return brands, nil
+}
+
+// ListBrands retrieves the distinct brands of the active cars in alphabetical order
+func (r *carRepository) ListBrands(ctx context.Context) ([]string, error) {
+	query := `SELECT DISTINCT brand FROM cars WHERE deleted_at IS NULL ORDER BY brand`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query)
+		return nil, fmt.Errorf("failed to list brands: %w", err)
+	}
+	defer rows.Close()
+
+	brands := make([]string, 0)
+	for rows.Next() {
+		var brand string
+		if err := rows.Scan(&brand); err != nil {
+			return nil, fmt.Errorf("failed to scan brand row: %w", err)
+		}
+		brands = append(brands, brand)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating brand rows: %w", err)
+	}
+
+	return brands, nil
+}
+
+// StatsByBrand computes price statistics of the active cars per brand, with
+// pagination, brands with the most cars first
+func (r *carRepository) StatsByBrand(ctx context.Context, page, pageSize int) ([]*model.BrandStatsResponse, error) {
+	offset := (page - 1) * pageSize
+
+	query := `
+		SELECT brand, COUNT(*) AS car_count, AVG(manufacturing_value), MIN(manufacturing_value), MAX(manufacturing_value)
+		FROM cars
+		WHERE deleted_at IS NULL
+		GROUP BY brand
+		ORDER BY car_count DESC, brand
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pageSize, offset)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, pageSize, offset)
+		return nil, fmt.Errorf("failed to get stats by brand: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]*model.BrandStatsResponse, 0)
+	for rows.Next() {
+		var brand model.BrandStatsResponse
+		if err := rows.Scan(&brand.Brand, &brand.Count, &brand.AvgPrice, &brand.MinPrice, &brand.MaxPrice); err != nil {
+			return nil, fmt.Errorf("failed to scan brand stats row: %w", err)
+		}
+		stats = append(stats, &brand)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating brand stats rows: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Update updates an existing car, provided it is still at car.Version. The
+// version is incremented, so a concurrent update based on the same version
+// fails with errs.ErrVersionConflict instead of being silently overwritten.
+func (r *carRepository) Update(ctx context.Context, car *model.Car) error {
+	query := `
+		UPDATE cars
+		SET name = $1, brand = $2, manufacturing_value = $3, description = $4, color = $5, year = NULLIF($6, 0),
+			featured = $7, weight = $8, updated_at = $9, version = version + 1
+		WHERE id = $10 AND version = $11 AND deleted_at IS NULL
+	`
+
+	car.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		car.Name,
+		car.Brand,
+		car.ManufacturingValue,
+		car.Description,
+		car.Color,
+		car.Year,
+		car.Featured,
+		car.Weight,
+		car.UpdatedAt,
+		car.ID,
+		car.Version,
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("car with name %s: %w", car.Name, errs.ErrDuplicateCar)
+		}
+		logger.LogSQLError(ctx, err, query, car.Name, car.Brand, car.ManufacturingValue, car.Description, car.Color, car.Year, car.Featured, car.Weight, car.UpdatedAt, car.ID, car.Version)
+		return fmt.Errorf("failed to update car: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// Tell a missing car apart from one whose version moved on
+		exists, err := r.Exists(ctx, car.ID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("car with ID %d: %w", car.ID, errs.ErrCarNotFound)
+		}
+		return fmt.Errorf("car with ID %d at version %d: %w", car.ID, car.Version, errs.ErrVersionConflict)
+	}
+
+	car.Version++
+
+	return nil
+}
+
+// Delete soft deletes a car by ID
+func (r *carRepository) Delete(ctx context.Context, id int64) error {
+	query := `
+		UPDATE cars
+		SET deleted_at = $1
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, id)
+		return fmt.Errorf("failed to delete car: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("car with ID %d: %w", id, errs.ErrCarNotFound)
+	}
+
+	return nil
+}
+
+// UpdatePrice sets the manufacturing value of an active car, leaving its other fields untouched
+func (r *carRepository) UpdatePrice(ctx context.Context, id int64, value model.Money) error {
+	query := `
+		UPDATE cars
+		SET manufacturing_value = $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND deleted_at IS NULL
+	`
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, value, now, id)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, value, now, id)
+		return fmt.Errorf("failed to update car price: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("car with ID %d: %w", id, errs.ErrCarNotFound)
+	}
+
+	return nil
+}
+
+// AdjustBrandPrices applies a percentage change to the prices of all active cars
+// of a brand in a single transaction and returns the IDs of the updated cars.
+// New prices are computed with model.Money.AdjustedBy, and nothing is updated
+// when any of them would fall outside the exclusive bounds minValue and maxValue.
+func (r *carRepository) AdjustBrandPrices(ctx context.Context, brand string, percent float64, minValue, maxValue model.Money) ([]int64, error) {
+	selectQuery := `
+		SELECT id, manufacturing_value
+		FROM cars
+		WHERE LOWER(brand) = LOWER($1) AND deleted_at IS NULL
+		ORDER BY id
+	` + lockRows(r.driver)
+	updateQuery := `
+		UPDATE cars
+		SET manufacturing_value = $1, updated_at = $2, version = version + 1
+		WHERE id = $3
+	`
+
+	var updated []int64
+	err := r.inTx(ctx, func(tx DBTX) error {
+		rows, err := tx.QueryContext(ctx, selectQuery, brand)
+		if err != nil {
+			logger.LogSQLError(ctx, err, selectQuery, brand)
+			return fmt.Errorf("failed to get prices to adjust: %w", err)
+		}
+
+		// The new prices are computed here, as previews compute them, rather
+		// than in SQL, where SQLite would round them in floating point
+		type adjustment struct {
+			id    int64
+			value model.Money
+		}
+		var adjustments []adjustment
+		outOfBounds := 0
+		for rows.Next() {
+			var id int64
+			var value model.Money
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan car price row: %w", err)
+			}
+
+			adjusted := value.AdjustedBy(percent)
+			if adjusted <= minValue || adjusted >= maxValue {
+				outOfBounds++
+			}
+			adjustments = append(adjustments, adjustment{id: id, value: adjusted})
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating car price rows: %w", err)
+		}
+
+		if outOfBounds > 0 {
+			return fmt.Errorf("%w: the change would put the price of %d %s cars outside the allowed range", errs.ErrInvalidInput, outOfBounds, brand)
+		}
+
+		now := time.Now()
+		updated = make([]int64, 0, len(adjustments))
+		for _, a := range adjustments {
+			if _, err := tx.ExecContext(ctx, updateQuery, a.value, now, a.id); err != nil {
+				logger.LogSQLError(ctx, err, updateQuery, a.value, now, a.id)
+				return fmt.Errorf("failed to adjust price of car with ID %d: %w", a.id, err)
+			}
+			updated = append(updated, a.id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// DeleteBatch soft deletes the active cars among ids in a single statement and
+// returns the IDs that were deleted
+func (r *carRepository) DeleteBatch(ctx context.Context, ids []int64) ([]int64, error) {
+	condition, idArgs := idInCondition(r.driver, ids, 2)
+	query := `
+		UPDATE cars
+		SET deleted_at = $1
+		WHERE ` + condition + ` AND deleted_at IS NULL
+		RETURNING id
+	`
+
+	var deleted []int64
+	err := r.inTx(ctx, func(tx DBTX) error {
+		args := append([]interface{}{time.Now()}, idArgs...)
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			logger.LogSQLError(ctx, err, query, args...)
+			return fmt.Errorf("failed to delete cars: %w", err)
+		}
+		defer rows.Close()
+
+		deleted = make([]int64, 0, len(ids))
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return fmt.Errorf("failed to scan deleted car ID: %w", err)
+			}
+			deleted = append(deleted, id)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating deleted car IDs: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// Merge folds a duplicate car into its primary inside a single transaction: the
+// primary inherits the duplicate's description when it has none, the duplicate
+// is soft deleted, and the merge is recorded in the audit log as done by actor
+func (r *carRepository) Merge(ctx context.Context, primaryID, duplicateID int64, actor sql.NullString) error {
+	lockQuery := `
+		SELECT id, description
+		FROM cars
+		WHERE id IN ($1, $2) AND deleted_at IS NULL
+		ORDER BY id
+	` + lockRows(r.driver)
+	copyQuery := `
+		UPDATE cars
+		SET description = $1, updated_at = $2, version = version + 1
+		WHERE id = $3
+	`
+	deleteQuery := `
+		UPDATE cars
+		SET deleted_at = $1
+		WHERE id = $2
+	`
+	auditQuery := `
+		INSERT INTO audit_log (car_id, event_type, actor, details, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	return r.inTx(ctx, func(tx DBTX) error {
+		// Lock both rows in ID order so concurrent merges can't deadlock
+		rows, err := tx.QueryContext(ctx, lockQuery, primaryID, duplicateID)
+		if err != nil {
+			logger.LogSQLError(ctx, err, lockQuery, primaryID, duplicateID)
+			return fmt.Errorf("failed to lock cars for merge: %w", err)
+		}
+
+		descriptions := make(map[int64]sql.NullString, 2)
+		for rows.Next() {
+			var id int64
+			var description sql.NullString
+			if err := rows.Scan(&id, &description); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan car row: %w", err)
+			}
+			descriptions[id] = description
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating car rows: %w", err)
+		}
+
+		for _, id := range []int64{primaryID, duplicateID} {
+			if _, ok := descriptions[id]; !ok {
+				return fmt.Errorf("car with ID %d: %w", id, errs.ErrCarNotFound)
+			}
+		}
+
+		now := time.Now()
+		primaryDescription := descriptions[primaryID]
+		duplicateDescription := descriptions[duplicateID]
+		copyDescription := strings.TrimSpace(primaryDescription.String) == "" &&
+			strings.TrimSpace(duplicateDescription.String) != ""
+
+		if copyDescription {
+			if _, err := tx.ExecContext(ctx, copyQuery, duplicateDescription, now, primaryID); err != nil {
+				logger.LogSQLError(ctx, err, copyQuery, duplicateDescription, now, primaryID)
+				return fmt.Errorf("failed to copy description: %w", err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteQuery, now, duplicateID); err != nil {
+			logger.LogSQLError(ctx, err, deleteQuery, now, duplicateID)
+			return fmt.Errorf("failed to delete duplicate car: %w", err)
+		}
+
+		details, err := json.Marshal(map[string]interface{}{
+			"duplicate_id":       duplicateID,
+			"description_copied": copyDescription,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode merge details: %w", err)
+		}
+
+		// Recorded in UTC like the events of auditRepository.Record, so List can filter on it
+		recordedAt := now.UTC()
+		if _, err := tx.ExecContext(ctx, auditQuery, primaryID, model.AuditEventMerged, actor, string(details), recordedAt); err != nil {
+			logger.LogSQLError(ctx, err, auditQuery, primaryID, model.AuditEventMerged, actor, string(details), recordedAt)
+			return fmt.Errorf("failed to record merge: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ScheduleDelete marks a car to be soft deleted once the given time has passed
+func (r *carRepository) ScheduleDelete(ctx context.Context, id int64, at time.Time) error {
+	query := `
+		UPDATE cars
+		SET delete_scheduled_at = $1
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, at, id)
+		return fmt.Errorf("failed to schedule car deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("car with ID %d: %w", id, errs.ErrCarNotFound)
+	}
+
+	return nil
+}
+
+// CancelScheduledDelete clears a pending scheduled deletion for a car
+func (r *carRepository) CancelScheduledDelete(ctx context.Context, id int64) error {
+	query := `
+		UPDATE cars
+		SET delete_scheduled_at = NULL
+		WHERE id = $1 AND deleted_at IS NULL AND delete_scheduled_at IS NOT NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, id)
+		return fmt.Errorf("failed to cancel scheduled car deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no scheduled deletion for car with ID %d: %w", id, errs.ErrCarNotFound)
+	}
+
+	return nil
+}
+
+// DeleteScheduled soft deletes every car whose scheduled deletion time has
+// passed, returning the IDs of the deleted cars
+func (r *carRepository) DeleteScheduled(ctx context.Context, now time.Time) ([]int64, error) {
+	query := `
+		UPDATE cars
+		SET deleted_at = $1
+		WHERE delete_scheduled_at <= $1 AND deleted_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, now)
+		return nil, fmt.Errorf("failed to delete scheduled cars: %w", err)
+	}
+	defer rows.Close()
+
+	return scanIDs(rows)
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes user input so it is matched literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// scanCar scans a single row selected with carColumns into a Car
+func scanCar(row rowScanner) (*model.Car, error) {
+	var car model.Car
+	if err := row.Scan(
+		&car.ID,
+		&car.Name,
+		&car.Brand,
+		&car.ManufacturingValue,
+		&car.Description,
+		&car.Color,
+		&car.Year,
+		&car.Featured,
+		&car.Weight,
+		&car.DeleteScheduledAt,
+		&car.DeletedAt,
+		&car.Version,
+		&car.CreatedAt,
+		&car.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &car, nil
+}
+
+// scanCars scans every row selected with carColumns into a slice of Car
+func scanCars(rows *sql.Rows) ([]*model.Car, error) {
+	var cars []*model.Car
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan car row: %w", err)
+		}
+		cars = append(cars, car)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating car rows: %w", err)
+	}
+
+	return cars, nil
+}
+
+// scanIDs scans every row of a single ID column
+func scanIDs(rows *sql.Rows) ([]int64, error) {
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan car ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating car IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+// PurgeDeletedBefore permanently removes the cars soft deleted before the given
+// time, returning their IDs. Their idempotency keys go with them; their audit
+// history is kept.
+func (r *carRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]int64, error) {
+	query := `
+		DELETE FROM cars
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		RETURNING id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, before)
+	if err != nil {
+		logger.LogSQLError(ctx, err, query, before)
+		return nil, fmt.Errorf("failed to purge deleted cars: %w", err)
+	}
+	defer rows.Close()
+
+	return scanIDs(rows)
 }
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/username/go-car-service/internal/errs"
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/pkg/database"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// TestMain runs the tests from the repository root, where the migrations live
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	logger.SetOutput(io.Discard)
+
+	if err := os.Chdir("../.."); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to change to the repository root: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestDB opens a migrated in-memory SQLite database private to the test
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := sql.Open(database.DriverSQLite, dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatalf("failed to enable foreign keys: %v", err)
+	}
+	if err := database.Migrate(db, database.DriverSQLite); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return db
+}
+
+// createTestCar inserts car, defaulting the year, value and weight when they are not set
+func createTestCar(t *testing.T, repo CarRepository, car *model.Car) int64 {
+	t.Helper()
+
+	if car.Year == 0 {
+		car.Year = 2020
+	}
+	if car.ManufacturingValue == 0 {
+		car.ManufacturingValue = model.MoneyFromFloat(25000)
+	}
+	if car.Weight == 0 {
+		car.Weight = 1
+	}
+
+	id, err := repo.Create(context.Background(), car)
+	if err != nil {
+		t.Fatalf("failed to create car %q: %v", car.Name, err)
+	}
+
+	return id
+}
+
+func TestGetAllCancelledContext(t *testing.T) {
+	repo := NewCarRepository(newTestDB(t), database.DriverSQLite, 0)
+
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+	t.Cleanup(func() { logger.SetOutput(io.Discard) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.GetAll(ctx, 1, 10)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetAll() error = %v, want context.Canceled", err)
+	}
+	if strings.Contains(logs.String(), "SQL error") {
+		t.Errorf("cancelled query was logged as an SQL error: %s", logs.String())
+	}
+}
+
+func TestSearchSQLite(t *testing.T) {
+	repo := NewCarRepository(newTestDB(t), database.DriverSQLite, 0)
+	createTestCar(t, repo, &model.Car{Name: "Civic", Brand: "Honda"})
+	createTestCar(t, repo, &model.Car{Name: "Accord", Brand: "HONDA"})
+	createTestCar(t, repo, &model.Car{Name: "Model 3", Brand: "Tesla", Description: sql.NullString{String: "100% electric", Valid: true}})
+	createTestCar(t, repo, &model.Car{Name: "Model_S", Brand: "Tesla"})
+
+	tests := []struct {
+		name       string
+		text       string
+		maxResults int
+		wantNames  []string
+		wantCapped bool
+	}{
+		{name: "matches brand in any case", text: "honda", wantNames: []string{"Civic", "Accord"}},
+		{name: "matches name", text: "CIVIC", wantNames: []string{"Civic"}},
+		{name: "matches description", text: "Electric", wantNames: []string{"Model 3"}},
+		{name: "percent is literal", text: "100%", wantNames: []string{"Model 3"}},
+		{name: "underscore is literal", text: "l_S", wantNames: []string{"Model_S"}},
+		{name: "no match", text: "ford", wantNames: []string{}},
+		{name: "capped", text: "o", maxResults: 2, wantNames: []string{"Civic", "Accord"}, wantCapped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cars, capped, err := repo.Search(context.Background(), tt.text, 1, 10, tt.maxResults)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+
+			names := make([]string, 0, len(cars))
+			for _, car := range cars {
+				names = append(names, car.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Search() = %v, want %v", names, tt.wantNames)
+			}
+			if capped != tt.wantCapped {
+				t.Errorf("Search() capped = %v, want %v", capped, tt.wantCapped)
+			}
+		})
+	}
+}
+
+func TestGetRandomFeaturedSQLite(t *testing.T) {
+	repo := NewCarRepository(newTestDB(t), database.DriverSQLite, 0)
+
+	if _, err := repo.GetRandomFeatured(context.Background()); !errors.Is(err, errs.ErrCarNotFound) {
+		t.Fatalf("GetRandomFeatured() without featured cars error = %v, want ErrCarNotFound", err)
+	}
+
+	createTestCar(t, repo, &model.Car{Name: "Civic", Brand: "Honda"})
+	lightID := createTestCar(t, repo, &model.Car{Name: "Fit", Brand: "Honda", Featured: true, Weight: 1})
+	heavyID := createTestCar(t, repo, &model.Car{Name: "Accord", Brand: "Honda", Featured: true, Weight: 9})
+
+	picks := map[int64]int{}
+	for i := 0; i < 500; i++ {
+		car, err := repo.GetRandomFeatured(context.Background())
+		if err != nil {
+			t.Fatalf("GetRandomFeatured() error = %v", err)
+		}
+		picks[car.ID]++
+	}
+
+	if picks[lightID]+picks[heavyID] != 500 {
+		t.Fatalf("GetRandomFeatured() picked a car that is not featured: %v", picks)
+	}
+	if picks[heavyID] <= picks[lightID] {
+		t.Errorf("GetRandomFeatured() picked the heavier car %d times and the lighter one %d times", picks[heavyID], picks[lightID])
+	}
+}
+
+func TestMergeSQLite(t *testing.T) {
+	description := sql.NullString{String: "Low mileage", Valid: true}
+
+	tests := []struct {
+		name                 string
+		primaryDescription   sql.NullString
+		duplicateDescription sql.NullString
+		actor                sql.NullString
+		wantDescription      string
+	}{
+		{name: "copies missing description", duplicateDescription: description, actor: sql.NullString{String: "admin", Valid: true}, wantDescription: "Low mileage"},
+		{name: "keeps primary description", primaryDescription: sql.NullString{String: "Mint", Valid: true}, duplicateDescription: description, actor: sql.NullString{String: "admin", Valid: true}, wantDescription: "Mint"},
+		{name: "nothing to copy", wantDescription: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			repo := NewCarRepository(db, database.DriverSQLite, 0)
+			primaryID := createTestCar(t, repo, &model.Car{Name: "Civic", Brand: "Honda", Description: tt.primaryDescription})
+			duplicateID := createTestCar(t, repo, &model.Car{Name: "Civic (2)", Brand: "Honda", Description: tt.duplicateDescription})
+
+			if err := repo.Merge(context.Background(), primaryID, duplicateID, tt.actor); err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+
+			primary, err := repo.GetByID(context.Background(), primaryID, false)
+			if err != nil {
+				t.Fatalf("GetByID(primary) error = %v", err)
+			}
+			if primary.Description.String != tt.wantDescription {
+				t.Errorf("primary description = %q, want %q", primary.Description.String, tt.wantDescription)
+			}
+
+			if _, err := repo.GetByID(context.Background(), duplicateID, false); !errors.Is(err, errs.ErrCarNotFound) {
+				t.Errorf("GetByID(duplicate) error = %v, want ErrCarNotFound", err)
+			}
+
+			since := time.Now().Add(-time.Minute)
+			merges, err := NewAuditRepository(db).List(context.Background(), model.AuditEventFilter{CarID: primaryID, EventType: model.AuditEventMerged, Since: &since, Limit: 10})
+			if err != nil {
+				t.Fatalf("failed to list merge events: %v", err)
+			}
+			if len(merges) != 1 {
+				t.Fatalf("merge events = %d, want 1", len(merges))
+			}
+			if merges[0].Actor != tt.actor {
+				t.Errorf("merge event actor = %v, want %v", merges[0].Actor, tt.actor)
+			}
+
+			if err := repo.Merge(context.Background(), primaryID, duplicateID, tt.actor); !errors.Is(err, errs.ErrCarNotFound) {
+				t.Errorf("Merge() of a deleted duplicate error = %v, want ErrCarNotFound", err)
+			}
+		})
+	}
+}
+
+func TestGetChangedSinceSQLite(t *testing.T) {
+	repo := NewCarRepository(newTestDB(t), database.DriverSQLite, 0)
+	since := time.Now().Add(-time.Minute)
+
+	civicID := createTestCar(t, repo, &model.Car{Name: "Civic", Brand: "Honda"})
+	accordID := createTestCar(t, repo, &model.Car{Name: "Accord", Brand: "Honda"})
+	fitID := createTestCar(t, repo, &model.Car{Name: "Fit", Brand: "Honda"})
+	if err := repo.Delete(context.Background(), civicID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	var pages [][]int64
+	after := model.ChangeCursorAt(since)
+	for len(pages) < 5 {
+		cars, err := repo.GetChangedSince(context.Background(), after, 2)
+		if err != nil {
+			t.Fatalf("GetChangedSince() error = %v", err)
+		}
+
+		ids := make([]int64, 0, len(cars))
+		for _, car := range cars {
+			ids = append(ids, car.ID)
+		}
+		pages = append(pages, ids)
+
+		if len(cars) < 2 {
+			break
+		}
+		after = model.ChangeCursorAfter(cars[len(cars)-1])
+	}
+
+	// The deleted Civic changed last, so it comes after the cars created later
+	want := fmt.Sprint([][]int64{{accordID, fitID}, {civicID}})
+	if got := fmt.Sprint(pages); got != want {
+		t.Errorf("GetChangedSince() pages = %s, want %s", got, want)
+	}
+
+	cars, err := repo.GetChangedSince(context.Background(), model.ChangeCursorAt(time.Now().Add(time.Minute)), 2)
+	if err != nil {
+		t.Fatalf("GetChangedSince() error = %v", err)
+	}
+	if len(cars) != 0 {
+		t.Errorf("GetChangedSince() after every change returned %d cars", len(cars))
+	}
+}
+
+func TestIdempotencyKeysSQLite(t *testing.T) {
+	repo := NewCarRepository(newTestDB(t), database.DriverSQLite, 0)
+	ctx := context.Background()
+	carID := createTestCar(t, repo, &model.Car{Name: "Civic", Brand: "Honda"})
+	expiredBefore := time.Now().Add(-IdempotencyKeyTTL)
+
+	reserve := func(client string, expiredBefore time.Time) bool {
+		t.Helper()
+		reserved, err := repo.ReserveIdempotencyKey(ctx, client, "key-1", expiredBefore)
+		if err != nil {
+			t.Fatalf("ReserveIdempotencyKey(%s) error = %v", client, err)
+		}
+		return reserved
+	}
+
+	if !reserve("ip:10.0.0.1", expiredBefore) {
+		t.Fatal("first reservation was refused")
+	}
+	if _, err := repo.GetIdempotentResponse(ctx, "ip:10.0.0.1", "key-1", expiredBefore); !errors.Is(err, errs.ErrCarNotFound) {
+		t.Errorf("GetIdempotentResponse() before the response is saved error = %v, want ErrCarNotFound", err)
+	}
+	if err := repo.SaveIdempotentResponse(ctx, "ip:10.0.0.1", "key-1", carID, []byte(`{"id":1}`)); err != nil {
+		t.Fatalf("SaveIdempotentResponse() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		client        string
+		expiredBefore time.Time
+		wantReserved  bool
+	}{
+		{name: "same client", client: "ip:10.0.0.1", expiredBefore: expiredBefore, wantReserved: false},
+		{name: "other client", client: "ip:10.0.0.2", expiredBefore: expiredBefore, wantReserved: true},
+		{name: "expired key", client: "ip:10.0.0.1", expiredBefore: time.Now().Add(time.Minute), wantReserved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := repo.GetIdempotentResponse(ctx, "ip:10.0.0.1", "key-1", expiredBefore)
+			if err != nil || string(response) != `{"id":1}` {
+				t.Fatalf("GetIdempotentResponse() = %s, %v; want the saved response", response, err)
+			}
+
+			if got := reserve(tt.client, tt.expiredBefore); got != tt.wantReserved {
+				t.Errorf("ReserveIdempotencyKey() = %v, want %v", got, tt.wantReserved)
+			}
+
+			// Keep the original reservation for the next cases
+			if tt.wantReserved {
+				if err := repo.SaveIdempotentResponse(ctx, tt.client, "key-1", carID, []byte(`{"id":1}`)); err != nil {
+					t.Fatalf("SaveIdempotentResponse() error = %v", err)
+				}
+			}
+		})
+	}
+
+	purged, err := repo.PurgeIdempotencyKeys(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeIdempotencyKeys() error = %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("PurgeIdempotencyKeys() = %d, want 2", purged)
+	}
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/username/go-car-service/pkg/database"
+)
+
+// pqUniqueViolation is the Postgres error code for a unique constraint violation
+const pqUniqueViolation = "23505"
+
+// execQueryer is satisfied by both *sql.DB and *sql.Tx
+type execQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertID runs an INSERT statement and returns the ID of the new row. Postgres
+// has no LastInsertId, so the ID is read back through RETURNING there; SQLite
+// reports it through LastInsertId.
+func insertID(ctx context.Context, q execQueryer, driver, query string, args ...interface{}) (int64, error) {
+	if driver == database.DriverSQLite {
+		result, err := q.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.LastInsertId()
+	}
+
+	var id int64
+	err := q.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+// idInCondition returns a condition matching rows whose id is one of ids, with its
+// arguments numbered from argPos. Postgres takes the IDs as a single array
+// parameter; SQLite has no arrays, so it gets one placeholder per ID.
+func idInCondition(driver string, ids []int64, argPos int) (string, []interface{}) {
+	if driver != database.DriverSQLite {
+		return fmt.Sprintf("id = ANY($%d)", argPos), []interface{}{pq.Array(ids)}
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", argPos+i)
+		args[i] = id
+	}
+	return "id IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// caseInsensitiveLike returns the operator matching a LIKE pattern regardless of
+// case. SQLite's LIKE already ignores the case of ASCII letters; it has no ILIKE.
+func caseInsensitiveLike(driver string) string {
+	if driver == database.DriverSQLite {
+		return "LIKE"
+	}
+	return "ILIKE"
+}
+
+// lockRows returns the clause locking the rows a SELECT reads until the end of
+// the transaction. SQLite has no row locks, as a writing transaction holds the
+// whole database, so it gets none.
+func lockRows(driver string) string {
+	if driver == database.DriverSQLite {
+		return ""
+	}
+	return "FOR UPDATE"
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqUniqueViolation
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+
+	return false
+}
+
+// aggregateTime scans a timestamp computed by an aggregate such as MIN into t.
+// SQLite returns those as text, as its driver only parses the values of columns
+// declared as timestamps.
+type aggregateTime struct {
+	t *sql.NullTime
+}
+
+// Scan implements sql.Scanner
+func (a aggregateTime) Scan(value interface{}) error {
+	text, ok := value.(string)
+	if !ok {
+		return a.t.Scan(value)
+	}
+
+	for _, format := range sqlite3.SQLiteTimestampFormats {
+		if parsed, err := time.ParseInLocation(format, text, time.UTC); err == nil {
+			*a.t = sql.NullTime{Time: parsed, Valid: true}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot scan %q into a time", text)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/username/go-car-service/pkg/database"
+)
+
+// memoryStores numbers the in-memory databases, so that every store gets its own
+var memoryStores atomic.Int64
+
+// NewMemoryStore creates a Store backed by a private in-memory SQLite database,
+// migrated before it is returned, for tests and local experiments. Its data is
+// gone once the store is closed. As with Migrate, the migrations are read from
+// migrations/sqlite under the working directory.
+func NewMemoryStore() (Store, error) {
+	dsn := fmt.Sprintf("file:memory-store-%d?mode=memory&cache=shared", memoryStores.Add(1))
+	db, err := sql.Open(database.DriverSQLite, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open in-memory database: %w", err)
+	}
+
+	// The database lives as long as its connection, so keep exactly one
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+
+	store := NewStore(db, database.DriverSQLite, ReadRetryPolicy{}, 0)
+	if err := store.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
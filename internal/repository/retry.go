@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// pqCannotConnectNow is reported by a Postgres server that is starting up or shutting down
+const pqCannotConnectNow = "57P03"
+
+// ReadRetryPolicy controls how reads failing with a transient error are retried.
+// The delay before a retry doubles with every attempt, starting from BaseDelay.
+type ReadRetryPolicy struct {
+	// Attempts is the total number of tries; 1 or less disables retries
+	Attempts  int
+	BaseDelay time.Duration
+}
+
+// retry calls fn until it succeeds, fails with an error that isn't transient,
+// or the attempts are used up. fn must be safe to run more than once.
+func (p ReadRetryPolicy) retry(ctx context.Context, operation string, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !isTransientError(err) || attempt >= p.Attempts {
+			return err
+		}
+
+		delay := p.BaseDelay << (attempt - 1)
+		logger.WithRequestID(ctx).Warnf("Retrying %s in %s after a transient error (attempt %d of %d): %v", operation, delay, attempt+1, p.Attempts, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isTransientError reports whether err means the database could not be reached,
+// as opposed to a query that failed and would fail again
+func isTransientError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// Class 08 covers connection exceptions
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08" || pqErr.Code == pqCannotConnectNow
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+)
+
+// retryingCarRepository decorates a CarRepository, retrying its reads when they
+// fail because the database is briefly unreachable. Writes and streams are
+// passed through as they are, since running them twice isn't safe.
+type retryingCarRepository struct {
+	CarRepository
+	policy ReadRetryPolicy
+}
+
+// NewRetryingCarRepository wraps next so that its reads are retried according to policy
+func NewRetryingCarRepository(next CarRepository, policy ReadRetryPolicy) CarRepository {
+	return &retryingCarRepository{CarRepository: next, policy: policy}
+}
+
+// GetByID retries CarRepository.GetByID on transient errors
+func (r *retryingCarRepository) GetByID(ctx context.Context, id int64, includeDeleted bool) (*model.Car, error) {
+	var car *model.Car
+	err := r.policy.retry(ctx, "GetByID", func() error {
+		var err error
+		car, err = r.CarRepository.GetByID(ctx, id, includeDeleted)
+		return err
+	})
+	return car, err
+}
+
+// Exists retries CarRepository.Exists on transient errors
+func (r *retryingCarRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	var exists bool
+	err := r.policy.retry(ctx, "Exists", func() error {
+		var err error
+		exists, err = r.CarRepository.Exists(ctx, id)
+		return err
+	})
+	return exists, err
+}
+
+// GetByIDs retries CarRepository.GetByIDs on transient errors
+func (r *retryingCarRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetByIDs", func() error {
+		var err error
+		cars, err = r.CarRepository.GetByIDs(ctx, ids)
+		return err
+	})
+	return cars, err
+}
+
+// GetByNameAndBrand retries CarRepository.GetByNameAndBrand on transient errors
+func (r *retryingCarRepository) GetByNameAndBrand(ctx context.Context, name, brand string, includeDeleted bool) (*model.Car, error) {
+	var car *model.Car
+	err := r.policy.retry(ctx, "GetByNameAndBrand", func() error {
+		var err error
+		car, err = r.CarRepository.GetByNameAndBrand(ctx, name, brand, includeDeleted)
+		return err
+	})
+	return car, err
+}
+
+// GetByName retries CarRepository.GetByName on transient errors
+func (r *retryingCarRepository) GetByName(ctx context.Context, name string, includeDeleted bool) (*model.Car, error) {
+	var car *model.Car
+	err := r.policy.retry(ctx, "GetByName", func() error {
+		var err error
+		car, err = r.CarRepository.GetByName(ctx, name, includeDeleted)
+		return err
+	})
+	return car, err
+}
+
+// GetIdempotentResponse retries CarRepository.GetIdempotentResponse on transient errors
+func (r *retryingCarRepository) GetIdempotentResponse(ctx context.Context, client, key string, expiredBefore time.Time) ([]byte, error) {
+	var response []byte
+	err := r.policy.retry(ctx, "GetIdempotentResponse", func() error {
+		var err error
+		response, err = r.CarRepository.GetIdempotentResponse(ctx, client, key, expiredBefore)
+		return err
+	})
+	return response, err
+}
+
+// GetByBrand retries CarRepository.GetByBrand on transient errors
+func (r *retryingCarRepository) GetByBrand(ctx context.Context, brand string) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetByBrand", func() error {
+		var err error
+		cars, err = r.CarRepository.GetByBrand(ctx, brand)
+		return err
+	})
+	return cars, err
+}
+
+// GetChangedSince retries CarRepository.GetChangedSince on transient errors
+func (r *retryingCarRepository) GetChangedSince(ctx context.Context, after model.ChangeCursor, limit int) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetChangedSince", func() error {
+		var err error
+		cars, err = r.CarRepository.GetChangedSince(ctx, after, limit)
+		return err
+	})
+	return cars, err
+}
+
+// GetByCreatedDate retries CarRepository.GetByCreatedDate on transient errors
+func (r *retryingCarRepository) GetByCreatedDate(ctx context.Context, date time.Time) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetByCreatedDate", func() error {
+		var err error
+		cars, err = r.CarRepository.GetByCreatedDate(ctx, date)
+		return err
+	})
+	return cars, err
+}
+
+// CountByBrand retries CarRepository.CountByBrand on transient errors
+func (r *retryingCarRepository) CountByBrand(ctx context.Context, brand string) (int64, error) {
+	var count int64
+	err := r.policy.retry(ctx, "CountByBrand", func() error {
+		var err error
+		count, err = r.CarRepository.CountByBrand(ctx, brand)
+		return err
+	})
+	return count, err
+}
+
+// GetByColor retries CarRepository.GetByColor on transient errors
+func (r *retryingCarRepository) GetByColor(ctx context.Context, color string) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetByColor", func() error {
+		var err error
+		cars, err = r.CarRepository.GetByColor(ctx, color)
+		return err
+	})
+	return cars, err
+}
+
+// GetByPriceRange retries CarRepository.GetByPriceRange on transient errors
+func (r *retryingCarRepository) GetByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetByPriceRange", func() error {
+		var err error
+		cars, err = r.CarRepository.GetByPriceRange(ctx, minPrice, maxPrice)
+		return err
+	})
+	return cars, err
+}
+
+// GetByYearRange retries CarRepository.GetByYearRange on transient errors
+func (r *retryingCarRepository) GetByYearRange(ctx context.Context, startYear, finalYear int) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetByYearRange", func() error {
+		var err error
+		cars, err = r.CarRepository.GetByYearRange(ctx, startYear, finalYear)
+		return err
+	})
+	return cars, err
+}
+
+// GetAll retries CarRepository.GetAll on transient errors
+func (r *retryingCarRepository) GetAll(ctx context.Context, page, pageSize int) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetAll", func() error {
+		var err error
+		cars, err = r.CarRepository.GetAll(ctx, page, pageSize)
+		return err
+	})
+	return cars, err
+}
+
+// Count retries CarRepository.Count on transient errors
+func (r *retryingCarRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.policy.retry(ctx, "Count", func() error {
+		var err error
+		count, err = r.CarRepository.Count(ctx)
+		return err
+	})
+	return count, err
+}
+
+// GetAfter retries CarRepository.GetAfter on transient errors
+func (r *retryingCarRepository) GetAfter(ctx context.Context, afterID int64, limit int) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetAfter", func() error {
+		var err error
+		cars, err = r.CarRepository.GetAfter(ctx, afterID, limit)
+		return err
+	})
+	return cars, err
+}
+
+// GetRelated retries CarRepository.GetRelated on transient errors
+func (r *retryingCarRepository) GetRelated(ctx context.Context, car *model.Car, limit int) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetRelated", func() error {
+		var err error
+		cars, err = r.CarRepository.GetRelated(ctx, car, limit)
+		return err
+	})
+	return cars, err
+}
+
+// GetRecent retries CarRepository.GetRecent on transient errors
+func (r *retryingCarRepository) GetRecent(ctx context.Context, limit int) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetRecent", func() error {
+		var err error
+		cars, err = r.CarRepository.GetRecent(ctx, limit)
+		return err
+	})
+	return cars, err
+}
+
+// GetCheapest retries CarRepository.GetCheapest on transient errors
+func (r *retryingCarRepository) GetCheapest(ctx context.Context) (*model.Car, error) {
+	var car *model.Car
+	err := r.policy.retry(ctx, "GetCheapest", func() error {
+		var err error
+		car, err = r.CarRepository.GetCheapest(ctx)
+		return err
+	})
+	return car, err
+}
+
+// GetMostExpensive retries CarRepository.GetMostExpensive on transient errors
+func (r *retryingCarRepository) GetMostExpensive(ctx context.Context) (*model.Car, error) {
+	var car *model.Car
+	err := r.policy.retry(ctx, "GetMostExpensive", func() error {
+		var err error
+		car, err = r.CarRepository.GetMostExpensive(ctx)
+		return err
+	})
+	return car, err
+}
+
+// GetAllDeleted retries CarRepository.GetAllDeleted on transient errors
+func (r *retryingCarRepository) GetAllDeleted(ctx context.Context, page, pageSize int) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "GetAllDeleted", func() error {
+		var err error
+		cars, err = r.CarRepository.GetAllDeleted(ctx, page, pageSize)
+		return err
+	})
+	return cars, err
+}
+
+// Filter retries CarRepository.Filter on transient errors
+func (r *retryingCarRepository) Filter(ctx context.Context, filter model.CarFilter) ([]*model.Car, error) {
+	var cars []*model.Car
+	err := r.policy.retry(ctx, "Filter", func() error {
+		var err error
+		cars, err = r.CarRepository.Filter(ctx, filter)
+		return err
+	})
+	return cars, err
+}
+
+// GetRandomFeatured retries CarRepository.GetRandomFeatured on transient errors
+func (r *retryingCarRepository) GetRandomFeatured(ctx context.Context) (*model.Car, error) {
+	var car *model.Car
+	err := r.policy.retry(ctx, "GetRandomFeatured", func() error {
+		var err error
+		car, err = r.CarRepository.GetRandomFeatured(ctx)
+		return err
+	})
+	return car, err
+}
+
+// Search retries CarRepository.Search on transient errors
+func (r *retryingCarRepository) Search(ctx context.Context, query string, page, pageSize, maxResults int) ([]*model.Car, bool, error) {
+	var cars []*model.Car
+	var capped bool
+	err := r.policy.retry(ctx, "Search", func() error {
+		var err error
+		cars, capped, err = r.CarRepository.Search(ctx, query, page, pageSize, maxResults)
+		return err
+	})
+	return cars, capped, err
+}
+
+// GetSummary retries CarRepository.GetSummary on transient errors
+func (r *retryingCarRepository) GetSummary(ctx context.Context) (*model.CatalogSummary, error) {
+	var result *model.CatalogSummary
+	err := r.policy.retry(ctx, "GetSummary", func() error {
+		var err error
+		result, err = r.CarRepository.GetSummary(ctx)
+		return err
+	})
+	return result, err
+}
+
+// GetBrandsByValue retries CarRepository.GetBrandsByValue on transient errors
+func (r *retryingCarRepository) GetBrandsByValue(ctx context.Context, limit int) ([]*model.BrandValueResponse, error) {
+	var result []*model.BrandValueResponse
+	err := r.policy.retry(ctx, "GetBrandsByValue", func() error {
+		var err error
+		result, err = r.CarRepository.GetBrandsByValue(ctx, limit)
+		return err
+	})
+	return result, err
+}
+
+// ListBrands retries CarRepository.ListBrands on transient errors
+func (r *retryingCarRepository) ListBrands(ctx context.Context) ([]string, error) {
+	var brands []string
+	err := r.policy.retry(ctx, "ListBrands", func() error {
+		var err error
+		brands, err = r.CarRepository.ListBrands(ctx)
+		return err
+	})
+	return brands, err
+}
+
+// StatsByBrand retries CarRepository.StatsByBrand on transient errors
+func (r *retryingCarRepository) StatsByBrand(ctx context.Context, page, pageSize int) ([]*model.BrandStatsResponse, error) {
+	var result []*model.BrandStatsResponse
+	err := r.policy.retry(ctx, "StatsByBrand", func() error {
+		var err error
+		result, err = r.CarRepository.StatsByBrand(ctx, page, pageSize)
+		return err
+	})
+	return result, err
+}
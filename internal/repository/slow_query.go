@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// slowQueryDB times every statement run through db and logs those taking
+// longer than threshold. Queries are timed until their first row is ready,
+// not while their rows are read.
+type slowQueryDB struct {
+	db        DBTX
+	threshold time.Duration
+}
+
+// withSlowQueryLog wraps db to log slow statements, or returns it unchanged
+// when threshold is not positive
+func withSlowQueryLog(db DBTX, threshold time.Duration) DBTX {
+	if threshold <= 0 {
+		return db
+	}
+
+	return &slowQueryDB{db: db, threshold: threshold}
+}
+
+func (s *slowQueryDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer s.observe(ctx, query, time.Now())
+	return s.db.ExecContext(ctx, query, args...)
+}
+
+func (s *slowQueryDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer s.observe(ctx, query, time.Now())
+	return s.db.QueryContext(ctx, query, args...)
+}
+
+func (s *slowQueryDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer s.observe(ctx, query, time.Now())
+	return s.db.QueryRowContext(ctx, query, args...)
+}
+
+// observe logs the query when it has been running for longer than the threshold since start
+func (s *slowQueryDB) observe(ctx context.Context, query string, start time.Time) {
+	if duration := time.Since(start); duration > s.threshold {
+		logger.LogSlowQuery(ctx, query, duration)
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/username/go-car-service/pkg/database"
+)
+
+// Store bundles the repositories of a storage backend with its lifecycle,
+// so callers don't depend on the database specifics
+type Store interface {
+	TxManager
+
+	Cars() CarRepository
+	Audit() AuditRepository
+
+	// Ping checks that the backend is reachable
+	Ping(ctx context.Context) error
+	// Migrate brings the backend schema up to date
+	Migrate() error
+	// SetNameUniqueness makes the names of active cars unique on their own, or
+	// only per brand with byBrand
+	SetNameUniqueness(ctx context.Context, byBrand bool) error
+	// MigrationStatus reports whether the backend schema is up to date
+	MigrationStatus(ctx context.Context) (*database.MigrationStatus, error)
+	// Stats reports connection pool usage
+	Stats() sql.DBStats
+	// Close releases the backend's resources
+	Close() error
+}
+
+type sqlStore struct {
+	db        *sql.DB
+	driver    string
+	slowQuery time.Duration
+	cars      CarRepository
+	audit     AuditRepository
+}
+
+// NewStore creates a Store backed by the given connection pool, opened with
+// one of the drivers supported by pkg/database. Car reads failing because the
+// database is briefly unreachable are retried according to readRetry, and car
+// statements slower than slowQuery are logged.
+func NewStore(db *sql.DB, driver string, readRetry ReadRetryPolicy, slowQuery time.Duration) Store {
+	return &sqlStore{
+		db:        db,
+		driver:    driver,
+		slowQuery: slowQuery,
+		cars:      NewTracingCarRepository(NewRetryingCarRepository(NewCarRepository(db, driver, slowQuery), readRetry)),
+		audit:     NewAuditRepository(db),
+	}
+}
+
+// Cars returns the car repository
+func (s *sqlStore) Cars() CarRepository {
+	return s.cars
+}
+
+// Audit returns the audit log repository
+func (s *sqlStore) Audit() AuditRepository {
+	return s.audit
+}
+
+// WithTx runs fn with a car repository bound to a single transaction. Reads
+// are not retried inside it, as a lost connection takes the transaction with it.
+func (s *sqlStore) WithTx(ctx context.Context, fn func(repo CarRepository) error) error {
+	return runInTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		return fn(NewTracingCarRepository(newTxCarRepository(tx, s.driver, s.slowQuery)))
+	})
+}
+
+// Ping checks the database connection
+func (s *sqlStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Migrate runs the pending database migrations
+func (s *sqlStore) Migrate() error {
+	return database.Migrate(s.db, s.driver)
+}
+
+// Unique indexes on the names of active cars, one per uniqueness mode. Brands
+// differing only in case are the same brand.
+const (
+	nameUniqueIndex      = "CREATE UNIQUE INDEX IF NOT EXISTS idx_cars_name_unique ON cars(name) WHERE deleted_at IS NULL"
+	nameBrandUniqueIndex = "CREATE UNIQUE INDEX IF NOT EXISTS idx_cars_name_brand_unique ON cars(name, LOWER(brand)) WHERE deleted_at IS NULL"
+)
+
+// SetNameUniqueness swaps the unique index on active car names for the one of
+// the requested mode. The new index is built before the old one is dropped, so
+// switching back to unique names fails, leaving things as they were, while two
+// brands still share a name.
+func (s *sqlStore) SetNameUniqueness(ctx context.Context, byBrand bool) error {
+	create, drop := nameUniqueIndex, "DROP INDEX IF EXISTS idx_cars_name_brand_unique"
+	if byBrand {
+		create, drop = nameBrandUniqueIndex, "DROP INDEX IF EXISTS idx_cars_name_unique"
+	}
+
+	return runInTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, create); err != nil {
+			return fmt.Errorf("failed to create car name unique index: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, drop); err != nil {
+			return fmt.Errorf("failed to drop car name unique index: %w", err)
+		}
+		return nil
+	})
+}
+
+// MigrationStatus reports the applied and expected migration versions
+func (s *sqlStore) MigrationStatus(ctx context.Context) (*database.MigrationStatus, error) {
+	return database.GetMigrationStatus(ctx, s.db, s.driver)
+}
+
+// Stats reports the connection pool statistics
+func (s *sqlStore) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Close closes the connection pool
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/pkg/tracing"
+)
+
+// tracingCarRepository decorates a CarRepository, wrapping every call in a span
+// named after the method and tagged with its SQL operation and, when it
+// concerns a single car, the car ID
+type tracingCarRepository struct {
+	CarRepository
+}
+
+// NewTracingCarRepository wraps next so that its calls are traced
+func NewTracingCarRepository(next CarRepository) CarRepository {
+	return &tracingCarRepository{CarRepository: next}
+}
+
+// Create traces CarRepository.Create
+func (r *tracingCarRepository) Create(ctx context.Context, car *model.Car) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.Create", tracing.DBOperationKey.String("INSERT"))
+	id, err := r.CarRepository.Create(ctx, car)
+	tracing.End(span, err)
+	return id, err
+}
+
+// CreateBatch traces CarRepository.CreateBatch
+func (r *tracingCarRepository) CreateBatch(ctx context.Context, cars []*model.Car) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.CreateBatch", tracing.DBOperationKey.String("INSERT"))
+	ids, err := r.CarRepository.CreateBatch(ctx, cars)
+	tracing.End(span, err)
+	return ids, err
+}
+
+// GetByID traces CarRepository.GetByID
+func (r *tracingCarRepository) GetByID(ctx context.Context, id int64, includeDeleted bool) (*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByID", tracing.CarIDKey.Int64(id), tracing.DBOperationKey.String("SELECT"))
+	car, err := r.CarRepository.GetByID(ctx, id, includeDeleted)
+	tracing.End(span, err)
+	return car, err
+}
+
+// Exists traces CarRepository.Exists
+func (r *tracingCarRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.Exists", tracing.CarIDKey.Int64(id), tracing.DBOperationKey.String("SELECT"))
+	exists, err := r.CarRepository.Exists(ctx, id)
+	tracing.End(span, err)
+	return exists, err
+}
+
+// GetByIDs traces CarRepository.GetByIDs
+func (r *tracingCarRepository) GetByIDs(ctx context.Context, ids []int64) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByIDs", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetByIDs(ctx, ids)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetByNameAndBrand traces CarRepository.GetByNameAndBrand
+func (r *tracingCarRepository) GetByNameAndBrand(ctx context.Context, name, brand string, includeDeleted bool) (*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByNameAndBrand", tracing.DBOperationKey.String("SELECT"))
+	car, err := r.CarRepository.GetByNameAndBrand(ctx, name, brand, includeDeleted)
+	tracing.End(span, err)
+	return car, err
+}
+
+// GetByName traces CarRepository.GetByName
+func (r *tracingCarRepository) GetByName(ctx context.Context, name string, includeDeleted bool) (*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByName", tracing.DBOperationKey.String("SELECT"))
+	car, err := r.CarRepository.GetByName(ctx, name, includeDeleted)
+	tracing.End(span, err)
+	return car, err
+}
+
+// ReserveIdempotencyKey traces CarRepository.ReserveIdempotencyKey
+func (r *tracingCarRepository) ReserveIdempotencyKey(ctx context.Context, client, key string, expiredBefore time.Time) (bool, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.ReserveIdempotencyKey", tracing.DBOperationKey.String("INSERT"))
+	reserved, err := r.CarRepository.ReserveIdempotencyKey(ctx, client, key, expiredBefore)
+	tracing.End(span, err)
+	return reserved, err
+}
+
+// GetIdempotentResponse traces CarRepository.GetIdempotentResponse
+func (r *tracingCarRepository) GetIdempotentResponse(ctx context.Context, client, key string, expiredBefore time.Time) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetIdempotentResponse", tracing.DBOperationKey.String("SELECT"))
+	response, err := r.CarRepository.GetIdempotentResponse(ctx, client, key, expiredBefore)
+	tracing.End(span, err)
+	return response, err
+}
+
+// SaveIdempotentResponse traces CarRepository.SaveIdempotentResponse
+func (r *tracingCarRepository) SaveIdempotentResponse(ctx context.Context, client, key string, carID int64, response []byte) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.SaveIdempotentResponse", tracing.DBOperationKey.String("UPDATE"))
+	err := r.CarRepository.SaveIdempotentResponse(ctx, client, key, carID, response)
+	tracing.End(span, err)
+	return err
+}
+
+// PurgeIdempotencyKeys traces CarRepository.PurgeIdempotencyKeys
+func (r *tracingCarRepository) PurgeIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.PurgeIdempotencyKeys", tracing.DBOperationKey.String("DELETE"))
+	purged, err := r.CarRepository.PurgeIdempotencyKeys(ctx, expiredBefore)
+	tracing.End(span, err)
+	return purged, err
+}
+
+// GetByBrand traces CarRepository.GetByBrand
+func (r *tracingCarRepository) GetByBrand(ctx context.Context, brand string) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByBrand", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetByBrand(ctx, brand)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetChangedSince traces CarRepository.GetChangedSince
+func (r *tracingCarRepository) GetChangedSince(ctx context.Context, after model.ChangeCursor, limit int) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetChangedSince", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetChangedSince(ctx, after, limit)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetByCreatedDate traces CarRepository.GetByCreatedDate
+func (r *tracingCarRepository) GetByCreatedDate(ctx context.Context, date time.Time) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByCreatedDate", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetByCreatedDate(ctx, date)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// CountByBrand traces CarRepository.CountByBrand
+func (r *tracingCarRepository) CountByBrand(ctx context.Context, brand string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.CountByBrand", tracing.DBOperationKey.String("SELECT"))
+	count, err := r.CarRepository.CountByBrand(ctx, brand)
+	tracing.End(span, err)
+	return count, err
+}
+
+// GetByColor traces CarRepository.GetByColor
+func (r *tracingCarRepository) GetByColor(ctx context.Context, color string) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByColor", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetByColor(ctx, color)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetByPriceRange traces CarRepository.GetByPriceRange
+func (r *tracingCarRepository) GetByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByPriceRange", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetByPriceRange(ctx, minPrice, maxPrice)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetByYearRange traces CarRepository.GetByYearRange
+func (r *tracingCarRepository) GetByYearRange(ctx context.Context, startYear, finalYear int) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetByYearRange", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetByYearRange(ctx, startYear, finalYear)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetAll traces CarRepository.GetAll
+func (r *tracingCarRepository) GetAll(ctx context.Context, page, pageSize int) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetAll", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetAll(ctx, page, pageSize)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// Count traces CarRepository.Count
+func (r *tracingCarRepository) Count(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.Count", tracing.DBOperationKey.String("SELECT"))
+	count, err := r.CarRepository.Count(ctx)
+	tracing.End(span, err)
+	return count, err
+}
+
+// GetAfter traces CarRepository.GetAfter
+func (r *tracingCarRepository) GetAfter(ctx context.Context, afterID int64, limit int) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetAfter", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetAfter(ctx, afterID, limit)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetRelated traces CarRepository.GetRelated
+func (r *tracingCarRepository) GetRelated(ctx context.Context, car *model.Car, limit int) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetRelated", tracing.CarIDKey.Int64(car.ID), tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetRelated(ctx, car, limit)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetRecent traces CarRepository.GetRecent
+func (r *tracingCarRepository) GetRecent(ctx context.Context, limit int) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetRecent", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetRecent(ctx, limit)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetCheapest traces CarRepository.GetCheapest
+func (r *tracingCarRepository) GetCheapest(ctx context.Context) (*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetCheapest", tracing.DBOperationKey.String("SELECT"))
+	car, err := r.CarRepository.GetCheapest(ctx)
+	tracing.End(span, err)
+	return car, err
+}
+
+// GetMostExpensive traces CarRepository.GetMostExpensive
+func (r *tracingCarRepository) GetMostExpensive(ctx context.Context) (*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetMostExpensive", tracing.DBOperationKey.String("SELECT"))
+	car, err := r.CarRepository.GetMostExpensive(ctx)
+	tracing.End(span, err)
+	return car, err
+}
+
+// GetAllDeleted traces CarRepository.GetAllDeleted
+func (r *tracingCarRepository) GetAllDeleted(ctx context.Context, page, pageSize int) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetAllDeleted", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.GetAllDeleted(ctx, page, pageSize)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// Filter traces CarRepository.Filter
+func (r *tracingCarRepository) Filter(ctx context.Context, filter model.CarFilter) ([]*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.Filter", tracing.DBOperationKey.String("SELECT"))
+	cars, err := r.CarRepository.Filter(ctx, filter)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// Stream traces CarRepository.Stream
+func (r *tracingCarRepository) Stream(ctx context.Context, brand string, fn func(car *model.Car) error) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.Stream", tracing.DBOperationKey.String("SELECT"))
+	err := r.CarRepository.Stream(ctx, brand, fn)
+	tracing.End(span, err)
+	return err
+}
+
+// GetRandomFeatured traces CarRepository.GetRandomFeatured
+func (r *tracingCarRepository) GetRandomFeatured(ctx context.Context) (*model.Car, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetRandomFeatured", tracing.DBOperationKey.String("SELECT"))
+	car, err := r.CarRepository.GetRandomFeatured(ctx)
+	tracing.End(span, err)
+	return car, err
+}
+
+// Search traces CarRepository.Search
+func (r *tracingCarRepository) Search(ctx context.Context, query string, page, pageSize, maxResults int) ([]*model.Car, bool, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.Search", tracing.DBOperationKey.String("SELECT"))
+	cars, capped, err := r.CarRepository.Search(ctx, query, page, pageSize, maxResults)
+	tracing.End(span, err)
+	return cars, capped, err
+}
+
+// GetSummary traces CarRepository.GetSummary
+func (r *tracingCarRepository) GetSummary(ctx context.Context) (*model.CatalogSummary, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetSummary", tracing.DBOperationKey.String("SELECT"))
+	result, err := r.CarRepository.GetSummary(ctx)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetBrandsByValue traces CarRepository.GetBrandsByValue
+func (r *tracingCarRepository) GetBrandsByValue(ctx context.Context, limit int) ([]*model.BrandValueResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.GetBrandsByValue", tracing.DBOperationKey.String("SELECT"))
+	result, err := r.CarRepository.GetBrandsByValue(ctx, limit)
+	tracing.End(span, err)
+	return result, err
+}
+
+// ListBrands traces CarRepository.ListBrands
+func (r *tracingCarRepository) ListBrands(ctx context.Context) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.ListBrands", tracing.DBOperationKey.String("SELECT"))
+	brands, err := r.CarRepository.ListBrands(ctx)
+	tracing.End(span, err)
+	return brands, err
+}
+
+// StatsByBrand traces CarRepository.StatsByBrand
+func (r *tracingCarRepository) StatsByBrand(ctx context.Context, page, pageSize int) ([]*model.BrandStatsResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.StatsByBrand", tracing.DBOperationKey.String("SELECT"))
+	result, err := r.CarRepository.StatsByBrand(ctx, page, pageSize)
+	tracing.End(span, err)
+	return result, err
+}
+
+// Update traces CarRepository.Update
+func (r *tracingCarRepository) Update(ctx context.Context, car *model.Car) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.Update", tracing.DBOperationKey.String("UPDATE"))
+	err := r.CarRepository.Update(ctx, car)
+	tracing.End(span, err)
+	return err
+}
+
+// UpdatePrice traces CarRepository.UpdatePrice
+func (r *tracingCarRepository) UpdatePrice(ctx context.Context, id int64, value model.Money) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.UpdatePrice", tracing.CarIDKey.Int64(id), tracing.DBOperationKey.String("UPDATE"))
+	err := r.CarRepository.UpdatePrice(ctx, id, value)
+	tracing.End(span, err)
+	return err
+}
+
+// AdjustBrandPrices traces CarRepository.AdjustBrandPrices
+func (r *tracingCarRepository) AdjustBrandPrices(ctx context.Context, brand string, percent float64, minValue, maxValue model.Money) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.AdjustBrandPrices", tracing.DBOperationKey.String("UPDATE"))
+	updated, err := r.CarRepository.AdjustBrandPrices(ctx, brand, percent, minValue, maxValue)
+	tracing.End(span, err)
+	return updated, err
+}
+
+// Delete traces CarRepository.Delete
+func (r *tracingCarRepository) Delete(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.Delete", tracing.CarIDKey.Int64(id), tracing.DBOperationKey.String("UPDATE"))
+	err := r.CarRepository.Delete(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+// DeleteBatch traces CarRepository.DeleteBatch
+func (r *tracingCarRepository) DeleteBatch(ctx context.Context, ids []int64) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.DeleteBatch", tracing.DBOperationKey.String("UPDATE"))
+	deleted, err := r.CarRepository.DeleteBatch(ctx, ids)
+	tracing.End(span, err)
+	return deleted, err
+}
+
+// Merge traces CarRepository.Merge
+func (r *tracingCarRepository) Merge(ctx context.Context, primaryID, duplicateID int64, actor sql.NullString) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.Merge", tracing.CarIDKey.Int64(primaryID), tracing.DBOperationKey.String("UPDATE"))
+	err := r.CarRepository.Merge(ctx, primaryID, duplicateID, actor)
+	tracing.End(span, err)
+	return err
+}
+
+// ScheduleDelete traces CarRepository.ScheduleDelete
+func (r *tracingCarRepository) ScheduleDelete(ctx context.Context, id int64, at time.Time) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.ScheduleDelete", tracing.CarIDKey.Int64(id), tracing.DBOperationKey.String("UPDATE"))
+	err := r.CarRepository.ScheduleDelete(ctx, id, at)
+	tracing.End(span, err)
+	return err
+}
+
+// CancelScheduledDelete traces CarRepository.CancelScheduledDelete
+func (r *tracingCarRepository) CancelScheduledDelete(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "CarRepository.CancelScheduledDelete", tracing.CarIDKey.Int64(id), tracing.DBOperationKey.String("UPDATE"))
+	err := r.CarRepository.CancelScheduledDelete(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+// DeleteScheduled traces CarRepository.DeleteScheduled
+func (r *tracingCarRepository) DeleteScheduled(ctx context.Context, now time.Time) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.DeleteScheduled", tracing.DBOperationKey.String("UPDATE"))
+	deleted, err := r.CarRepository.DeleteScheduled(ctx, now)
+	tracing.End(span, err)
+	return deleted, err
+}
+
+// PurgeDeletedBefore traces CarRepository.PurgeDeletedBefore
+func (r *tracingCarRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "CarRepository.PurgeDeletedBefore", tracing.DBOperationKey.String("DELETE"))
+	purged, err := r.CarRepository.PurgeDeletedBefore(ctx, before)
+	tracing.End(span, err)
+	return purged, err
+}
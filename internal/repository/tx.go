@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+const (
+	// maxTxAttempts bounds how many times a transaction is run before giving up
+	maxTxAttempts = 3
+
+	// txRetryBaseDelay is the backoff before the first retry; it doubles on every attempt
+	txRetryBaseDelay = 20 * time.Millisecond
+)
+
+// Postgres error codes for transactions that are safe to retry
+const (
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+)
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx, so a repository can run its
+// statements either on the pool or inside a transaction
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// TxManager runs units of work atomically
+type TxManager interface {
+	// WithTx runs fn with a CarRepository whose statements all run in one
+	// transaction, committed when fn returns nil and rolled back otherwise.
+	// The transaction may be retried, so fn must be safe to run more than once.
+	WithTx(ctx context.Context, fn func(repo CarRepository) error) error
+}
+
+// runInTx runs fn inside a transaction, committing when it returns nil and
+// rolling back otherwise. Transactions aborted because of a serialization
+// failure or a deadlock are retried with jittered backoff, so fn must only
+// touch the database through tx and be safe to run more than once. When ctx
+// ends during the backoff, the returned error wraps both ctx's error and the
+// last attempt's.
+func runInTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = runTxOnce(ctx, db, opts, fn)
+		if err == nil || !isRetryableTxError(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		delay := txRetryBaseDelay << (attempt - 1)
+		delay += time.Duration(rand.Int63n(int64(delay)))
+		logger.WithRequestID(ctx).Debugf("Retrying transaction in %s (attempt %d of %d): %v", delay, attempt+1, maxTxAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w while retrying transaction: %w", ctx.Err(), err)
+		case <-time.After(delay):
+		}
+	}
+
+	return err
+}
+
+// runTxOnce runs fn inside a single transaction attempt
+func runTxOnce(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// isRetryableTxError reports whether err is a Postgres serialization failure or deadlock
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected
+	}
+
+	return false
+}
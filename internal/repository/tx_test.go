@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestRunInTx(t *testing.T) {
+	serializationFailure := &pq.Error{Code: pqSerializationFailure}
+	deadlock := &pq.Error{Code: pqDeadlockDetected}
+	uniqueViolation := &pq.Error{Code: "23505"}
+
+	tests := []struct {
+		name         string
+		failures     []error
+		cancelFirst  bool
+		wantAttempts int
+		wantErr      []error
+		wantRows     int
+	}{
+		{name: "first attempt", wantAttempts: 1, wantRows: 1},
+		{name: "serialization failure retried", failures: []error{serializationFailure}, wantAttempts: 2, wantRows: 1},
+		{name: "deadlock retried", failures: []error{deadlock, deadlock}, wantAttempts: 3, wantRows: 1},
+		{name: "gives up", failures: []error{serializationFailure, serializationFailure, serializationFailure}, wantAttempts: maxTxAttempts, wantErr: []error{serializationFailure}},
+		{name: "other errors not retried", failures: []error{uniqueViolation}, wantAttempts: 1, wantErr: []error{uniqueViolation}},
+		{name: "canceled during backoff", failures: []error{serializationFailure}, cancelFirst: true, wantAttempts: 1, wantErr: []error{context.Canceled, serializationFailure}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			attempts := 0
+			err := runInTx(ctx, db, nil, func(tx *sql.Tx) error {
+				attempts++
+				if _, err := tx.ExecContext(ctx, `INSERT INTO idempotency_keys (client, key, created_at) VALUES ('test', $1, CURRENT_TIMESTAMP)`, attempts); err != nil {
+					return err
+				}
+				if attempts <= len(tt.failures) {
+					if tt.cancelFirst {
+						cancel()
+					}
+					return tt.failures[attempts-1]
+				}
+				return nil
+			})
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("ran %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("runInTx() error = %v, want it to wrap %v", err, want)
+				}
+			}
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Fatalf("runInTx() error = %v", err)
+			}
+
+			// Only the successful attempt's insert survives
+			var committed int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM idempotency_keys`).Scan(&committed); err != nil {
+				t.Fatalf("failed to count rows: %v", err)
+			}
+			if committed != tt.wantRows {
+				t.Errorf("committed %d rows, want %d", committed, tt.wantRows)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/username/go-car-service/internal/errs"
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/internal/repository"
+)
+
+const (
+	// defaultAuditPageSize is used when no limit is requested
+	defaultAuditPageSize = 50
+
+	// maxAuditPageSize is the largest page of audit events that can be requested
+	maxAuditPageSize = 500
+)
+
+// AuditService defines the interface for audit log business logic
+type AuditService interface {
+	ListEvents(ctx context.Context, filter model.AuditEventFilter) (*model.AuditEventPage, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+// NewAuditService creates a new instance of AuditService
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// ListEvents retrieves a page of audit events, across all cars unless the filter names one
+func (s *auditService) ListEvents(ctx context.Context, filter model.AuditEventFilter) (*model.AuditEventPage, error) {
+	if filter.CarID < 0 {
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	if filter.AfterID < 0 {
+		return nil, fmt.Errorf("%w: cursor cannot be negative", errs.ErrInvalidInput)
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = defaultAuditPageSize
+	}
+
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+
+	events, err := s.repo.List(ctx, filter)
+	if err != nil {
+		logError(ctx, err, "Failed to list audit events: %v", err)
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	page := &model.AuditEventPage{
+		Events: make([]*model.AuditEventResponse, 0, len(events)),
+	}
+	for _, event := range events {
+		page.Events = append(page.Events, event.ToResponse())
+	}
+
+	// A full page means there may be more events after the last one
+	if len(events) == filter.Limit {
+		nextCursor := events[len(events)-1].ID
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/username/go-car-service/internal/errs"
+	"github.com/username/go-car-service/internal/model"
+)
+
+func TestListEvents(t *testing.T) {
+	ctx := context.Background()
+	_, store := newTestService(t, nil)
+	svc := NewAuditService(store.Audit())
+
+	before := time.Now().Add(-time.Minute)
+	types := []string{"car.created", "car.updated", "car.created", "car.deleted", "car.updated"}
+	ids := make([]int64, 0, len(types))
+	for i, eventType := range types {
+		event := &model.AuditEvent{CarID: int64(i%2 + 1), EventType: eventType}
+		if err := store.Audit().Record(ctx, event); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		ids = append(ids, event.ID)
+	}
+	after := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name       string
+		filter     model.AuditEventFilter
+		want       []int64
+		wantCursor *int64
+		wantErr    error
+	}{
+		{name: "all, oldest first", filter: model.AuditEventFilter{}, want: ids},
+		{name: "by type", filter: model.AuditEventFilter{EventType: "car.created"}, want: []int64{ids[0], ids[2]}},
+		{name: "by car", filter: model.AuditEventFilter{CarID: 2}, want: []int64{ids[1], ids[3]}},
+		{name: "since before every event", filter: model.AuditEventFilter{Since: &before}, want: ids},
+		{name: "since after every event", filter: model.AuditEventFilter{Since: &after}, want: nil},
+		{name: "full page", filter: model.AuditEventFilter{Limit: 2}, want: ids[:2], wantCursor: &ids[1]},
+		{name: "next page", filter: model.AuditEventFilter{AfterID: ids[1], Limit: 2}, want: ids[2:4], wantCursor: &ids[3]},
+		{name: "last page", filter: model.AuditEventFilter{AfterID: ids[3], Limit: 2}, want: ids[4:]},
+		{name: "filtered page", filter: model.AuditEventFilter{EventType: "car.updated", Limit: 1}, want: ids[1:2], wantCursor: &ids[1]},
+		{name: "negative cursor", filter: model.AuditEventFilter{AfterID: -1}, wantErr: errs.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := svc.ListEvents(ctx, tt.filter)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ListEvents() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+
+			var got []int64
+			for _, event := range page.Events {
+				got = append(got, event.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ListEvents(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+			if (page.NextCursor == nil) != (tt.wantCursor == nil) || (page.NextCursor != nil && *page.NextCursor != *tt.wantCursor) {
+				t.Errorf("nextCursor = %v, want %v", page.NextCursor, tt.wantCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/internal/repository"
+)
+
+// actorKey is the context key under which the acting user is stored
+type actorKey struct{}
+
+// ContextWithActor returns a copy of ctx carrying the user performing the request
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFromContext returns the acting user stored in ctx, if any
+func actorFromContext(ctx context.Context) sql.NullString {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return sql.NullString{String: actor, Valid: actor != ""}
+}
+
+// auditingCarService decorates a CarService, recording every successful write
+// in the audit log. A failure to record is logged but never fails the write,
+// which has already happened by then.
+type auditingCarService struct {
+	CarService
+	audit repository.AuditRepository
+}
+
+// NewAuditingCarService wraps next so that its writes are recorded in audit
+func NewAuditingCarService(next CarService, audit repository.AuditRepository) CarService {
+	return &auditingCarService{CarService: next, audit: audit}
+}
+
+// CreateCar creates a car and records its creation. A replayed request created
+// nothing, so it is not recorded again.
+func (s *auditingCarService) CreateCar(ctx context.Context, req *model.CarRequest, idempotencyKey IdempotencyKey) (*model.CarResponse, bool, error) {
+	car, replayed, err := s.CarService.CreateCar(ctx, req, idempotencyKey)
+	if err == nil && !replayed {
+		s.record(ctx, car.ID, model.AuditEventCreated, car)
+	}
+	return car, replayed, err
+}
+
+// CreateCars creates several cars and records each creation
+func (s *auditingCarService) CreateCars(ctx context.Context, reqs []*model.CarRequest) ([]*model.CarResponse, error) {
+	cars, err := s.CarService.CreateCars(ctx, reqs)
+	if err == nil {
+		for _, car := range cars {
+			s.record(ctx, car.ID, model.AuditEventCreated, car)
+		}
+	}
+	return cars, err
+}
+
+// UpdateCar updates a car and records the update
+func (s *auditingCarService) UpdateCar(ctx context.Context, id int64, req *model.CarRequest) (*model.CarResponse, error) {
+	car, err := s.CarService.UpdateCar(ctx, id, req)
+	if err == nil {
+		s.record(ctx, car.ID, model.AuditEventUpdated, car)
+	}
+	return car, err
+}
+
+// PatchCar patches a car and records the update
+func (s *auditingCarService) PatchCar(ctx context.Context, id int64, patch []byte, version int) (*model.CarResponse, error) {
+	car, err := s.CarService.PatchCar(ctx, id, patch, version)
+	if err == nil {
+		s.record(ctx, car.ID, model.AuditEventUpdated, car)
+	}
+	return car, err
+}
+
+// UpdatePrice updates a car's price and records the update
+func (s *auditingCarService) UpdatePrice(ctx context.Context, id int64, value model.Money) (*model.CarResponse, error) {
+	car, err := s.CarService.UpdatePrice(ctx, id, value)
+	if err == nil {
+		s.record(ctx, car.ID, model.AuditEventUpdated, car)
+	}
+	return car, err
+}
+
+// UpdateBrandPrices updates a brand's prices and records an update of each car
+func (s *auditingCarService) UpdateBrandPrices(ctx context.Context, brand string, percentChange float64) (*model.BrandPriceUpdateResponse, error) {
+	result, err := s.CarService.UpdateBrandPrices(ctx, brand, percentChange)
+	if err == nil {
+		details := map[string]interface{}{"brand": brand, "percentChange": percentChange}
+		for _, id := range result.IDs {
+			s.record(ctx, id, model.AuditEventUpdated, details)
+		}
+	}
+	return result, err
+}
+
+// UpsertCarByName upserts a car and records its creation or update
+func (s *auditingCarService) UpsertCarByName(ctx context.Context, name string, req *model.CarRequest) (*model.CarResponse, bool, error) {
+	car, created, err := s.CarService.UpsertCarByName(ctx, name, req)
+	if err == nil {
+		eventType := model.AuditEventUpdated
+		if created {
+			eventType = model.AuditEventCreated
+		}
+		s.record(ctx, car.ID, eventType, car)
+	}
+	return car, created, err
+}
+
+// DeleteCar deletes a car and records the deletion
+func (s *auditingCarService) DeleteCar(ctx context.Context, id int64) error {
+	err := s.CarService.DeleteCar(ctx, id)
+	if err == nil {
+		s.record(ctx, id, model.AuditEventDeleted, nil)
+	}
+	return err
+}
+
+// DeleteCars deletes several cars and records each deletion
+func (s *auditingCarService) DeleteCars(ctx context.Context, ids []int64) (*model.DeleteCarsResponse, error) {
+	result, err := s.CarService.DeleteCars(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	notFound := make(map[int64]bool, len(result.NotFound))
+	for _, id := range result.NotFound {
+		notFound[id] = true
+	}
+	for _, id := range ids {
+		if !notFound[id] {
+			s.record(ctx, id, model.AuditEventDeleted, nil)
+			// Record an ID listed twice only once
+			notFound[id] = true
+		}
+	}
+
+	return result, nil
+}
+
+// ProcessScheduledDeletions deletes the due cars and records each deletion
+func (s *auditingCarService) ProcessScheduledDeletions(ctx context.Context) ([]int64, error) {
+	deleted, err := s.CarService.ProcessScheduledDeletions(ctx)
+	for _, id := range deleted {
+		s.record(ctx, id, model.AuditEventDeleted, nil)
+	}
+	return deleted, err
+}
+
+// PurgeDeletedCars purges the expired deleted cars and records each purge
+func (s *auditingCarService) PurgeDeletedCars(ctx context.Context) ([]int64, error) {
+	purged, err := s.CarService.PurgeDeletedCars(ctx)
+	for _, id := range purged {
+		s.record(ctx, id, model.AuditEventPurged, nil)
+	}
+	return purged, err
+}
+
+// record writes an audit event for a car, with details such as the car's new state when given
+func (s *auditingCarService) record(ctx context.Context, carID int64, eventType string, details interface{}) {
+	event := &model.AuditEvent{
+		CarID:     carID,
+		EventType: eventType,
+		Actor:     actorFromContext(ctx),
+		CreatedAt: time.Now(),
+	}
+
+	if details != nil {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			logError(ctx, err, "Failed to encode audit details of car %d: %v", carID, err)
+		} else {
+			event.Details = sql.NullString{String: string(encoded), Valid: true}
+		}
+	}
+
+	if err := s.audit.Record(ctx, event); err != nil {
+		logError(ctx, err, "Failed to record %s audit event for car %d: %v", eventType, carID, err)
+	}
+}
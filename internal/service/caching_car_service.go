@@ -0,0 +1,225 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+)
+
+// cachingCarService decorates a CarService with an LRU cache for GetCarByID.
+// Every method that can change a car drops the cached entries it affects.
+type cachingCarService struct {
+	CarService
+	cache *carCache
+}
+
+// NewCachingCarService wraps next with a cache of at most maxEntries cars,
+// each kept for up to ttl
+func NewCachingCarService(next CarService, ttl time.Duration, maxEntries int) CarService {
+	return &cachingCarService{
+		CarService: next,
+		cache:      newCarCache(ttl, maxEntries),
+	}
+}
+
+// GetCarByID serves the car from the cache when possible
+func (s *cachingCarService) GetCarByID(ctx context.Context, id int64) (*model.CarResponse, error) {
+	if car, ok := s.cache.get(id); ok {
+		return car, nil
+	}
+
+	car, err := s.CarService.GetCarByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.put(id, car)
+	return car, nil
+}
+
+// UpdateCar updates a car and drops it from the cache
+func (s *cachingCarService) UpdateCar(ctx context.Context, id int64, req *model.CarRequest) (*model.CarResponse, error) {
+	defer s.cache.remove(id)
+	return s.CarService.UpdateCar(ctx, id, req)
+}
+
+// PatchCar patches a car and drops it from the cache
+func (s *cachingCarService) PatchCar(ctx context.Context, id int64, patch []byte, version int) (*model.CarResponse, error) {
+	defer s.cache.remove(id)
+	return s.CarService.PatchCar(ctx, id, patch, version)
+}
+
+// UpdatePrice updates a car's price and drops it from the cache
+func (s *cachingCarService) UpdatePrice(ctx context.Context, id int64, value model.Money) (*model.CarResponse, error) {
+	defer s.cache.remove(id)
+	return s.CarService.UpdatePrice(ctx, id, value)
+}
+
+// UpdateBrandPrices updates a brand's prices and drops the updated cars from the cache
+func (s *cachingCarService) UpdateBrandPrices(ctx context.Context, brand string, percentChange float64) (*model.BrandPriceUpdateResponse, error) {
+	result, err := s.CarService.UpdateBrandPrices(ctx, brand, percentChange)
+	if result != nil {
+		for _, id := range result.IDs {
+			s.cache.remove(id)
+		}
+	}
+	return result, err
+}
+
+// UpsertCarByName upserts a car and drops it from the cache
+func (s *cachingCarService) UpsertCarByName(ctx context.Context, name string, req *model.CarRequest) (*model.CarResponse, bool, error) {
+	car, created, err := s.CarService.UpsertCarByName(ctx, name, req)
+	if car != nil {
+		s.cache.remove(car.ID)
+	}
+	return car, created, err
+}
+
+// DeleteCar deletes a car and drops it from the cache
+func (s *cachingCarService) DeleteCar(ctx context.Context, id int64) error {
+	defer s.cache.remove(id)
+	return s.CarService.DeleteCar(ctx, id)
+}
+
+// DeleteCars deletes several cars and drops them from the cache
+func (s *cachingCarService) DeleteCars(ctx context.Context, ids []int64) (*model.DeleteCarsResponse, error) {
+	defer s.cache.remove(ids...)
+	return s.CarService.DeleteCars(ctx, ids)
+}
+
+// MergeCars merges two cars and drops both from the cache
+func (s *cachingCarService) MergeCars(ctx context.Context, req *model.MergeCarsRequest) (*model.CarResponse, error) {
+	defer s.cache.remove(req.PrimaryID, req.DuplicateID)
+	return s.CarService.MergeCars(ctx, req)
+}
+
+// ScheduleCarDeletion schedules a deletion and drops the car from the cache
+func (s *cachingCarService) ScheduleCarDeletion(ctx context.Context, id int64, at time.Time) (*model.CarResponse, error) {
+	defer s.cache.remove(id)
+	return s.CarService.ScheduleCarDeletion(ctx, id, at)
+}
+
+// CancelCarDeletion cancels a scheduled deletion and drops the car from the cache
+func (s *cachingCarService) CancelCarDeletion(ctx context.Context, id int64) (*model.CarResponse, error) {
+	defer s.cache.remove(id)
+	return s.CarService.CancelCarDeletion(ctx, id)
+}
+
+// ProcessScheduledDeletions deletes the due cars and drops them from the cache
+func (s *cachingCarService) ProcessScheduledDeletions(ctx context.Context) ([]int64, error) {
+	deleted, err := s.CarService.ProcessScheduledDeletions(ctx)
+	for _, id := range deleted {
+		s.cache.remove(id)
+	}
+	return deleted, err
+}
+
+// carCacheEntry is a cached car with its expiry
+type carCacheEntry struct {
+	id        int64
+	car       *model.CarResponse
+	expiresAt time.Time
+}
+
+// carCache is a concurrency-safe LRU cache of cars keyed by ID, with a TTL per entry
+type carCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // front is the most recently used
+	entries    map[int64]*list.Element
+}
+
+func newCarCache(ttl time.Duration, maxEntries int) *carCache {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+
+	return &carCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[int64]*list.Element),
+	}
+}
+
+// get returns a copy of the cached car. Expired entries and cars whose scheduled
+// deletion is due count as misses, since the deletion job may run elsewhere.
+func (c *carCache) get(id int64) (*model.CarResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*carCacheEntry)
+	now := time.Now()
+	if now.After(entry.expiresAt) || deletionDue(entry.car, now) {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	car := *entry.car
+	return &car, true
+}
+
+// put caches a copy of the car, evicting the least recently used entry when full
+func (c *carCache) put(id int64, car *model.CarResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := *car
+	entry := &carCacheEntry{id: id, car: &cached, expiresAt: time.Now().Add(c.ttl)}
+
+	if elem, ok := c.entries[id]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[id] = c.order.PushFront(entry)
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*carCacheEntry).id)
+	}
+}
+
+// remove drops the given cars from the cache
+func (c *carCache) remove(ids ...int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ids {
+		if elem, ok := c.entries[id]; ok {
+			c.order.Remove(elem)
+			delete(c.entries, id)
+		}
+	}
+}
+
+// clear drops every cached car
+func (c *carCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[int64]*list.Element)
+}
+
+// deletionDue reports whether the car's scheduled deletion time has passed
+func deletionDue(car *model.CarResponse, now time.Time) bool {
+	if car.DeleteScheduledAt == nil {
+		return false
+	}
+
+	at, err := time.Parse(time.RFC3339, *car.DeleteScheduledAt)
+	return err == nil && !now.Before(at)
+}
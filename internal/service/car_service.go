@@ -2,123 +2,570 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/username/go-car-service/internal/config"
+	"github.com/username/go-car-service/internal/errs"
 	"github.com/username/go-car-service/internal/model"
 	"github.com/username/go-car-service/internal/repository"
 	"github.com/username/go-car-service/pkg/logger"
+	"golang.org/x/sync/errgroup"
 )
 
 // CarService defines the interface for car business logic
 type CarService interface {
-	CreateCar(ctx context.Context, req *model.CarRequest) (*model.CarResponse, error)
+	CreateCar(ctx context.Context, req *model.CarRequest, idempotencyKey IdempotencyKey) (*model.CarResponse, bool, error)
+	CreateCars(ctx context.Context, reqs []*model.CarRequest) ([]*model.CarResponse, error)
+	ValidateCars(ctx context.Context, reqs []*model.CarRequest) (*model.BatchValidationResponse, error)
 	GetCarByID(ctx context.Context, id int64) (*model.CarResponse, error)
-	GetCarByName(ctx context.Context, name string) (*model.CarResponse, error)
+	GetCarByIDIncludingDeleted(ctx context.Context, id int64) (*model.DeletedCarResponse, error)
+	GetCarsByIDs(ctx context.Context, ids []int64) (*model.CarBatchResponse, error)
+	GetCarByName(ctx context.Context, name, brand string) (*model.CarResponse, error)
+	CheckNameAvailable(ctx context.Context, name, brand string) (*model.NameAvailabilityResponse, error)
 	GetCarsByBrand(ctx context.Context, brand string) ([]*model.CarResponse, error)
+	GetCarsByColor(ctx context.Context, color string) ([]*model.CarResponse, error)
+	GetCarsCreatedOn(ctx context.Context, date time.Time) ([]*model.CarResponse, error)
+	GetCarChanges(ctx context.Context, after model.ChangeCursor, pageSize int) (*model.CarChangesResponse, error)
+	CountCars(ctx context.Context) (*model.CarCountResponse, error)
+	CountCarsByBrand(ctx context.Context, brand string) (*model.BrandCountResponse, error)
 	GetCarsByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*model.CarResponse, error)
+	GetCarsByYearRange(ctx context.Context, startYear, finalYear int) ([]*model.CarResponse, error)
 	GetAllCars(ctx context.Context, page, pageSize int) ([]*model.CarResponse, error)
+	GetCarsPage(ctx context.Context, page, pageSize int) (*model.CarListPage, error)
+	GetCarsAfter(ctx context.Context, afterID int64, pageSize int) (*model.CarPage, error)
+	GetRecentCars(ctx context.Context, limit int) ([]*model.CarResponse, error)
+	GetRelatedCars(ctx context.Context, id int64, limit int) (*model.RelatedCarsResponse, error)
+	GetCarExtremes(ctx context.Context) (*model.CarExtremesResponse, error)
+	GetDeletedCars(ctx context.Context, page, pageSize int) ([]*model.DeletedCarResponse, error)
+	FilterCars(ctx context.Context, filter model.CarFilter) ([]*model.CarResponse, error)
+	StreamCars(ctx context.Context, fn func(car *model.CarResponse) error) error
+	StreamCarsByBrand(ctx context.Context, brand string, fn func(car *model.CarResponse) error) error
+	GetRandomFeaturedCar(ctx context.Context) (*model.CarResponse, error)
+	SearchCars(ctx context.Context, query string, page, pageSize int) ([]*model.CarResponse, bool, error)
+	GetCatalogSummary(ctx context.Context) (*model.CatalogSummaryResponse, error)
+	GetBrandsByValue(ctx context.Context, limit int) ([]*model.BrandValueResponse, error)
+	ListBrands(ctx context.Context) ([]string, error)
+	GetStatsByBrand(ctx context.Context, page, pageSize int) ([]*model.BrandStatsResponse, error)
 	UpdateCar(ctx context.Context, id int64, req *model.CarRequest) (*model.CarResponse, error)
+	PatchCar(ctx context.Context, id int64, patch []byte, version int) (*model.CarResponse, error)
+	UpdatePrice(ctx context.Context, id int64, value model.Money) (*model.CarResponse, error)
+	UpdateBrandPrices(ctx context.Context, brand string, percentChange float64) (*model.BrandPriceUpdateResponse, error)
+	UpsertCarByName(ctx context.Context, name string, req *model.CarRequest) (*model.CarResponse, bool, error)
 	DeleteCar(ctx context.Context, id int64) error
+	DeleteCars(ctx context.Context, ids []int64) (*model.DeleteCarsResponse, error)
+	MergeCars(ctx context.Context, req *model.MergeCarsRequest) (*model.CarResponse, error)
+	ScheduleCarDeletion(ctx context.Context, id int64, at time.Time) (*model.CarResponse, error)
+	CancelCarDeletion(ctx context.Context, id int64) (*model.CarResponse, error)
+	ProcessScheduledDeletions(ctx context.Context) ([]int64, error)
+	PurgeDeletedCars(ctx context.Context) ([]int64, error)
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+	ValidateField(ctx context.Context, req *model.FieldValidationRequest) (*model.FieldValidationResponse, error)
+	GetCarSchema(ctx context.Context) (*model.CarSchemaResponse, error)
+	PreviewPriceAdjustment(ctx context.Context, req *model.PriceAdjustmentRequest, page, pageSize int) (*model.PriceAdjustmentPreviewResponse, error)
+}
+
+// MaxBatchSize is the maximum number of cars accepted by a single bulk request
+const MaxBatchSize = 500
+
+// Bounds for the number of recent cars returned, and the number used when none is given
+const (
+	DefaultRecentLimit = 10
+	MaxRecentLimit     = 50
+)
+
+// Bounds for the number of related cars returned, and the number used when none is given
+const (
+	DefaultRelatedLimit = 5
+	MaxRelatedLimit     = 50
+)
+
+// IdempotencyKey is a client-chosen key making car creation safe to retry. Keys
+// are scoped to the client sending them, so clients can't replay each other's
+// requests; an empty Key disables idempotency.
+type IdempotencyKey struct {
+	Client string
+	Key    string
+}
+
+// BatchItemError reports which entry of a bulk request failed
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("car at index %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
 }
 
 type carService struct {
 	repo repository.CarRepository
+	txm  repository.TxManager
+	cfg  *config.Config
 }
 
-// NewCarService creates a new instance of CarService
-func NewCarService(repo repository.CarRepository) CarService {
-	return &carService{repo: repo}
+// NewCarService creates a new instance of CarService. Writes followed by reads
+// that must see them run in a transaction of txm; with a nil txm, they run
+// directly on repo.
+func NewCarService(repo repository.CarRepository, txm repository.TxManager, cfg *config.Config) CarService {
+	return &carService{repo: repo, txm: txm, cfg: cfg}
 }
 
-// CreateCar creates a new car
-func (s *carService) CreateCar(ctx context.Context, req *model.CarRequest) (*model.CarResponse, error) {
+// CreateCar creates a new car. When the client already used idempotencyKey
+// within repository.IdempotencyKeyTTL, the response of that request is returned
+// instead and reported as replayed.
+func (s *carService) CreateCar(ctx context.Context, req *model.CarRequest, idempotencyKey IdempotencyKey) (*model.CarResponse, bool, error) {
 	// Validate request
-	if err := validateCarRequest(req); err != nil {
-		return nil, err
+	if err := s.validateCarRequest(req); err != nil {
+		return nil, false, err
 	}
 
+	// A retried request replays the response of the original one
+	expiredBefore := time.Now().Add(-repository.IdempotencyKeyTTL)
+	if idempotencyKey.Key != "" {
+		response, err := s.replayResponse(ctx, s.repo, idempotencyKey, expiredBefore)
+		if err == nil {
+			return response, true, nil
+		}
+		if !errors.Is(err, errs.ErrCarNotFound) {
+			return nil, false, err
+		}
+	}
+
+	s.normalizeRequest(req)
+
 	// Convert request to model
 	car := req.ToModel()
 
-	// Check if car with the same name already exists
-	existingCar, err := s.repo.GetByName(ctx, car.Name)
+	// Names are only unique among active cars: a deleted car's name may be reused
+	// by a new car, while the deleted car stays untouched in the trash. So only
+	// an active car with the name blocks the creation.
+	existingCar, err := s.findByName(ctx, car.Name, car.Brand, false)
 	if err == nil && existingCar != nil {
-		return nil, fmt.Errorf("car with name %s already exists", car.Name)
+		return nil, false, fmt.Errorf("car with name %s: %w", s.nameKey(car.Name, car.Brand), errs.ErrDuplicateCar)
+	}
+
+	// Create the car, read it back and store the response in one transaction, so
+	// a concurrent delete can't make the read fail. The idempotency key is reserved
+	// first: a concurrent request with the same key waits for this transaction,
+	// then replays its response.
+	var response *model.CarResponse
+	var replayed bool
+	err = s.withTx(ctx, func(repo repository.CarRepository) error {
+		if idempotencyKey.Key != "" {
+			reserved, err := repo.ReserveIdempotencyKey(ctx, idempotencyKey.Client, idempotencyKey.Key, expiredBefore)
+			if err != nil {
+				logError(ctx, err, "Failed to reserve idempotency key %s: %v", idempotencyKey.Key, err)
+				return fmt.Errorf("failed to reserve idempotency key: %w", err)
+			}
+
+			if replayed = !reserved; replayed {
+				response, err = s.replayResponse(ctx, repo, idempotencyKey, expiredBefore)
+				if errors.Is(err, errs.ErrCarNotFound) {
+					return fmt.Errorf("idempotency key %s is in use: %w", idempotencyKey.Key, errs.ErrDuplicateCar)
+				}
+				return err
+			}
+		}
+
+		id, err := repo.Create(ctx, car)
+		if err != nil {
+			logError(ctx, err, "Failed to create car: %v", err)
+			return fmt.Errorf("failed to create car: %w", err)
+		}
+
+		createdCar, err := repo.GetByID(ctx, id, false)
+		if err != nil {
+			logError(ctx, err, "Failed to fetch created car: %v", err)
+			return fmt.Errorf("failed to fetch created car: %w", err)
+		}
+		response = createdCar.ToResponse()
+
+		if idempotencyKey.Key != "" {
+			stored, err := json.Marshal(response)
+			if err != nil {
+				return fmt.Errorf("failed to encode response: %w", err)
+			}
+			if err := repo.SaveIdempotentResponse(ctx, idempotencyKey.Client, idempotencyKey.Key, id, stored); err != nil {
+				logError(ctx, err, "Failed to save idempotency key %s for car with ID %d: %v", idempotencyKey.Key, id, err)
+				return fmt.Errorf("failed to save idempotency key: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return response, replayed, nil
+}
+
+// replayResponse decodes the response stored for an idempotency key; a key with
+// no unexpired response yields ErrCarNotFound
+func (s *carService) replayResponse(ctx context.Context, repo repository.CarRepository, idempotencyKey IdempotencyKey, expiredBefore time.Time) (*model.CarResponse, error) {
+	stored, err := repo.GetIdempotentResponse(ctx, idempotencyKey.Client, idempotencyKey.Key, expiredBefore)
+	if err != nil {
+		if !errors.Is(err, errs.ErrCarNotFound) {
+			logError(ctx, err, "Failed to look up idempotency key %s: %v", idempotencyKey.Key, err)
+			return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+		}
+		return nil, err
 	}
 
-	// Create car in repository
-	id, err := s.repo.Create(ctx, car)
+	var response model.CarResponse
+	if err := json.Unmarshal(stored, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode stored response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// CreateCars creates several cars at once. Every entry is validated before
+// anything is written, and the batch is inserted atomically.
+func (s *carService) CreateCars(ctx context.Context, reqs []*model.CarRequest) ([]*model.CarResponse, error) {
+	itemErrs, err := s.validateBatch(ctx, reqs)
 	if err != nil {
-		logger.Errorf("Failed to create car: %v", err)
-		return nil, fmt.Errorf("failed to create car: %v", err)
+		return nil, err
+	}
+
+	if len(itemErrs) > 0 {
+		return nil, itemErrs[0]
+	}
+
+	cars := make([]*model.Car, 0, len(reqs))
+	for _, req := range reqs {
+		cars = append(cars, req.ToModel())
+	}
+
+	if _, err := s.repo.CreateBatch(ctx, cars); err != nil {
+		logError(ctx, err, "Failed to create %d cars: %v", len(cars), err)
+		return nil, fmt.Errorf("failed to create cars: %w", err)
 	}
 
-	// Get the created car
-	createdCar, err := s.repo.GetByID(ctx, id)
+	return toCarResponses(cars), nil
+}
+
+// ValidateCars runs the validation of CreateCars on a bulk request without
+// creating anything, reporting every invalid entry
+func (s *carService) ValidateCars(ctx context.Context, reqs []*model.CarRequest) (*model.BatchValidationResponse, error) {
+	itemErrs, err := s.validateBatch(ctx, reqs)
 	if err != nil {
-		logger.Errorf("Failed to fetch created car: %v", err)
-		return nil, fmt.Errorf("failed to fetch created car: %v", err)
+		return nil, err
+	}
+
+	result := &model.BatchValidationResponse{
+		Valid:  len(itemErrs) == 0,
+		Errors: make([]model.BatchValidationError, 0, len(itemErrs)),
+	}
+	for _, itemErr := range itemErrs {
+		result.Errors = append(result.Errors, model.BatchValidationError{Index: itemErr.Index, Message: itemErr.Err.Error()})
 	}
 
-	return createdCar.ToResponse(), nil
+	return result, nil
+}
+
+// validateBatch validates and normalizes every entry of a bulk create request,
+// returning one error per invalid entry. An error is only returned when the
+// batch as a whole cannot be accepted.
+func (s *carService) validateBatch(ctx context.Context, reqs []*model.CarRequest) ([]*BatchItemError, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%w: at least one car is required", errs.ErrInvalidInput)
+	}
+
+	if len(reqs) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: cannot create more than %d cars at once", errs.ErrInvalidInput, MaxBatchSize)
+	}
+
+	var itemErrs []*BatchItemError
+	seen := make(map[string]int, len(reqs))
+	for i, req := range reqs {
+		if err := s.validateCarRequest(req); err != nil {
+			itemErrs = append(itemErrs, &BatchItemError{Index: i, Err: err})
+			continue
+		}
+
+		s.normalizeRequest(req)
+
+		// Reject names repeated within the batch itself; brands differing only in case are the same brand
+		key := s.nameKey(req.Name, req.Brand)
+		seenKey := s.nameKey(req.Name, strings.ToLower(req.Brand))
+		if first, ok := seen[seenKey]; ok {
+			itemErrs = append(itemErrs, &BatchItemError{Index: i, Err: fmt.Errorf("car with name %s is already used at index %d: %w", key, first, errs.ErrDuplicateCar)})
+			continue
+		}
+		seen[seenKey] = i
+
+		// Reject names that already exist in the catalog
+		existingCar, err := s.findByName(ctx, req.Name, req.Brand, false)
+		if err == nil && existingCar != nil {
+			itemErrs = append(itemErrs, &BatchItemError{Index: i, Err: fmt.Errorf("car with name %s: %w", key, errs.ErrDuplicateCar)})
+		}
+	}
+
+	return itemErrs, nil
 }
 
 // GetCarByID retrieves a car by its ID
 func (s *carService) GetCarByID(ctx context.Context, id int64) (*model.CarResponse, error) {
 	if id <= 0 {
-		return nil, errors.New("invalid car ID")
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
 	}
 
-	car, err := s.repo.GetByID(ctx, id)
+	car, err := s.repo.GetByID(ctx, id, false)
 	if err != nil {
-		logger.Errorf("Failed to get car by ID %d: %v", id, err)
-		return nil, fmt.Errorf("failed to get car: %v", err)
+		logError(ctx, err, "Failed to get car by ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to get car: %w", err)
 	}
 
 	return car.ToResponse(), nil
 }
 
-// GetCarByName retrieves a car by its name
-func (s *carService) GetCarByName(ctx context.Context, name string) (*model.CarResponse, error) {
+// GetCarByIDIncludingDeleted retrieves a car by its ID even when it was soft
+// deleted, along with its deletion time
+func (s *carService) GetCarByIDIncludingDeleted(ctx context.Context, id int64) (*model.DeletedCarResponse, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	car, err := s.repo.GetByID(ctx, id, true)
+	if err != nil {
+		logError(ctx, err, "Failed to get car by ID %d including deleted: %v", id, err)
+		return nil, fmt.Errorf("failed to get car: %w", err)
+	}
+
+	return car.ToDeletedResponse(), nil
+}
+
+// GetCarsByIDs retrieves several cars at once, in the order of ids. IDs that
+// do not match an active car are reported as missing.
+func (s *carService) GetCarsByIDs(ctx context.Context, ids []int64) (*model.CarBatchResponse, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: at least one car ID is required", errs.ErrInvalidInput)
+	}
+
+	if len(ids) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: cannot get more than %d cars at once", errs.ErrInvalidInput, MaxBatchSize)
+	}
+
+	cars, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		logError(ctx, err, "Failed to get %d cars by ID: %v", len(ids), err)
+		return nil, fmt.Errorf("failed to get cars: %w", err)
+	}
+
+	byID := make(map[int64]*model.Car, len(cars))
+	for _, car := range cars {
+		byID[car.ID] = car
+	}
+
+	result := &model.CarBatchResponse{
+		Cars:    make([]*model.CarResponse, 0, len(cars)),
+		Missing: make([]int64, 0),
+	}
+	for _, id := range ids {
+		if car, ok := byID[id]; ok {
+			result.Cars = append(result.Cars, car.ToResponse())
+		} else {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+
+	return result, nil
+}
+
+// GetCarByName retrieves a car by its name, and by its brand when one is given.
+// When names are only unique per brand, a name alone may match several cars, so
+// the brand is required.
+func (s *carService) GetCarByName(ctx context.Context, name, brand string) (*model.CarResponse, error) {
 	if name == "" {
-		return nil, errors.New("car name cannot be empty")
+		return nil, fmt.Errorf("%w: car name cannot be empty", errs.ErrInvalidInput)
 	}
 
-	car, err := s.repo.GetByName(ctx, name)
+	brand = s.canonicalBrand(strings.TrimSpace(brand))
+	if brand == "" && s.cfg.UniqueByNameBrand {
+		return nil, fmt.Errorf("%w: brand is required as names are unique per brand", errs.ErrInvalidInput)
+	}
+
+	var car *model.Car
+	var err error
+	if brand != "" {
+		car, err = s.repo.GetByNameAndBrand(ctx, name, brand, false)
+	} else {
+		car, err = s.repo.GetByName(ctx, name, false)
+	}
 	if err != nil {
-		logger.Errorf("Failed to get car by name %s: %v", name, err)
-		return nil, fmt.Errorf("failed to get car: %v", err)
+		logError(ctx, err, "Failed to get car by name %s: %v", s.nameKey(name, brand), err)
+		return nil, fmt.Errorf("failed to get car: %w", err)
 	}
 
 	return car.ToResponse(), nil
 }
 
+// CheckNameAvailable reports whether a new car could take the given name. Like
+// on create, the names of deleted cars are free to reuse. When names are only
+// unique per brand, the brand is required and the pair is checked.
+func (s *carService) CheckNameAvailable(ctx context.Context, name, brand string) (*model.NameAvailabilityResponse, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: car name cannot be empty", errs.ErrInvalidInput)
+	}
+
+	response := &model.NameAvailabilityResponse{Name: name}
+	if s.cfg.UniqueByNameBrand {
+		brand = s.canonicalBrand(strings.TrimSpace(brand))
+		if brand == "" {
+			return nil, fmt.Errorf("%w: brand is required as names are unique per brand", errs.ErrInvalidInput)
+		}
+		response.Brand = brand
+	}
+
+	_, err := s.findByName(ctx, name, brand, false)
+	if err != nil && !errors.Is(err, errs.ErrCarNotFound) {
+		logError(ctx, err, "Failed to get car by name %s: %v", s.nameKey(name, brand), err)
+		return nil, fmt.Errorf("failed to check name availability: %w", err)
+	}
+
+	response.Available = err != nil
+	return response, nil
+}
+
 // GetCarsByBrand retrieves all cars by brand
 func (s *carService) GetCarsByBrand(ctx context.Context, brand string) ([]*model.CarResponse, error) {
 	if brand == "" {
-		return nil, errors.New("brand name cannot be empty")
+		return nil, fmt.Errorf("%w: brand name cannot be empty", errs.ErrInvalidInput)
 	}
 
+	brand = s.canonicalBrand(brand)
 	cars, err := s.repo.GetByBrand(ctx, brand)
 	if err != nil {
-		logger.Errorf("Failed to get cars by brand %s: %v", brand, err)
-		return nil, fmt.Errorf("failed to get cars by brand: %v", err)
+		logError(ctx, err, "Failed to get cars by brand %s: %v", brand, err)
+		return nil, fmt.Errorf("failed to get cars by brand: %w", err)
 	}
 
 	return toCarResponses(cars), nil
 }
 
+// GetCarsByColor retrieves the cars of a color, matched case-insensitively
+func (s *carService) GetCarsByColor(ctx context.Context, color string) ([]*model.CarResponse, error) {
+	if color == "" {
+		return nil, fmt.Errorf("%w: color cannot be empty", errs.ErrInvalidInput)
+	}
+
+	cars, err := s.repo.GetByColor(ctx, color)
+	if err != nil {
+		logError(ctx, err, "Failed to get cars by color %s: %v", color, err)
+		return nil, fmt.Errorf("failed to get cars by color: %w", err)
+	}
+
+	return toCarResponses(cars), nil
+}
+
+// GetCarsCreatedOn retrieves the cars created on the calendar day of date
+func (s *carService) GetCarsCreatedOn(ctx context.Context, date time.Time) ([]*model.CarResponse, error) {
+	cars, err := s.repo.GetByCreatedDate(ctx, date)
+	if err != nil {
+		logError(ctx, err, "Failed to get cars created on %s: %v", date.Format(time.DateOnly), err)
+		return nil, fmt.Errorf("failed to get cars by creation date: %w", err)
+	}
+
+	return toCarResponses(cars), nil
+}
+
+// GetCarChanges retrieves a page of the cars changed after the cursor, deleted
+// ones included, for clients keeping a local copy in sync. The server time is
+// taken before the lookup, so a client passing the last page's one back as the
+// next since misses no change. Cars purged since are not reported.
+func (s *carService) GetCarChanges(ctx context.Context, after model.ChangeCursor, pageSize int) (*model.CarChangesResponse, error) {
+	serverTime := time.Now()
+	_, pageSize = s.normalizePagination(1, pageSize)
+
+	cars, err := s.repo.GetChangedSince(ctx, after, pageSize)
+	if err != nil {
+		logError(ctx, err, "Failed to get cars changed after %s: %v", after, err)
+		return nil, fmt.Errorf("failed to get changed cars: %w", err)
+	}
+
+	changes := make([]*model.CarChangeResponse, 0, len(cars))
+	for _, car := range cars {
+		changes = append(changes, car.ToChangeResponse())
+	}
+
+	response := &model.CarChangesResponse{
+		Changes:    changes,
+		ServerTime: serverTime.UTC().Format(time.RFC3339),
+	}
+
+	// A full page means there may be more changes after the last one
+	if len(cars) == pageSize {
+		nextCursor := model.ChangeCursorAfter(cars[len(cars)-1]).String()
+		response.NextCursor = &nextCursor
+	}
+
+	return response, nil
+}
+
+// CountCars counts the active cars
+func (s *carService) CountCars(ctx context.Context) (*model.CarCountResponse, error) {
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		logError(ctx, err, "Failed to count cars: %v", err)
+		return nil, fmt.Errorf("failed to count cars: %w", err)
+	}
+
+	return &model.CarCountResponse{Count: count}, nil
+}
+
+// CountCarsByBrand counts the active cars of a brand
+func (s *carService) CountCarsByBrand(ctx context.Context, brand string) (*model.BrandCountResponse, error) {
+	if brand == "" {
+		return nil, fmt.Errorf("%w: brand name cannot be empty", errs.ErrInvalidInput)
+	}
+
+	brand = s.canonicalBrand(brand)
+	count, err := s.repo.CountByBrand(ctx, brand)
+	if err != nil {
+		logError(ctx, err, "Failed to count cars by brand %s: %v", brand, err)
+		return nil, fmt.Errorf("failed to count cars by brand: %w", err)
+	}
+
+	return &model.BrandCountResponse{Brand: brand, Count: count}, nil
+}
+
 // GetCarsByPriceRange retrieves all cars within a price range
 func (s *carService) GetCarsByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*model.CarResponse, error) {
 	if minPrice < 0 || maxPrice < 0 || minPrice > maxPrice {
-		return nil, errors.New("invalid price range")
+		return nil, fmt.Errorf("%w: invalid price range", errs.ErrInvalidInput)
 	}
 
 	cars, err := s.repo.GetByPriceRange(ctx, minPrice, maxPrice)
 	if err != nil {
-		logger.Errorf("Failed to get cars by price range %.2f-%.2f: %v", minPrice, maxPrice, err)
-		return nil, fmt.Errorf("failed to get cars by price range: %v", err)
+		logError(ctx, err, "Failed to get cars by price range %.2f-%.2f: %v", minPrice, maxPrice, err)
+		return nil, fmt.Errorf("failed to get cars by price range: %w", err)
+	}
+
+	return toCarResponses(cars), nil
+}
+
+// GetCarsByYearRange retrieves all cars whose model year is within a range
+func (s *carService) GetCarsByYearRange(ctx context.Context, startYear, finalYear int) ([]*model.CarResponse, error) {
+	if startYear < MinYear || finalYear > MaxYear || startYear > finalYear {
+		return nil, fmt.Errorf("%w: invalid year range", errs.ErrInvalidInput)
+	}
+
+	cars, err := s.repo.GetByYearRange(ctx, startYear, finalYear)
+	if err != nil {
+		logError(ctx, err, "Failed to get cars by year range %d-%d: %v", startYear, finalYear, err)
+		return nil, fmt.Errorf("failed to get cars by year range: %w", err)
 	}
 
 	return toCarResponses(cars), nil
@@ -126,104 +573,825 @@ func (s *carService) GetCarsByPriceRange(ctx context.Context, minPrice, maxPrice
 
 // GetAllCars retrieves all cars with pagination
 func (s *carService) GetAllCars(ctx context.Context, page, pageSize int) ([]*model.CarResponse, error) {
-	if page < 1 {
-		page = 1
-	}
+	page, pageSize = s.normalizePagination(page, pageSize)
 
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10 // Default page size
+	cars, err := s.repo.GetAll(ctx, page, pageSize)
+	if err != nil {
+		logError(ctx, err, "Failed to get all cars (page %d, size %d): %v", page, pageSize, err)
+		return nil, fmt.Errorf("failed to get all cars: %w", err)
 	}
 
+	return toCarResponses(cars), nil
+}
+
+// GetCarsPage retrieves a page of cars like GetAllCars, along with the total
+// number of active cars
+func (s *carService) GetCarsPage(ctx context.Context, page, pageSize int) (*model.CarListPage, error) {
+	page, pageSize = s.normalizePagination(page, pageSize)
+
 	cars, err := s.repo.GetAll(ctx, page, pageSize)
 	if err != nil {
-		logger.Errorf("Failed to get all cars (page %d, size %d): %v", page, pageSize, err)
-		return nil, fmt.Errorf("failed to get all cars: %v", err)
+		logError(ctx, err, "Failed to get all cars (page %d, size %d): %v", page, pageSize, err)
+		return nil, fmt.Errorf("failed to get all cars: %w", err)
+	}
+
+	total, err := s.repo.Count(ctx)
+	if err != nil {
+		logError(ctx, err, "Failed to count cars: %v", err)
+		return nil, fmt.Errorf("failed to count cars: %w", err)
+	}
+
+	return &model.CarListPage{
+		Cars:     toCarResponses(cars),
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// GetCarsAfter retrieves a page of cars with an ID above the afterID cursor, in
+// ID order, along with the cursor of the next page
+func (s *carService) GetCarsAfter(ctx context.Context, afterID int64, pageSize int) (*model.CarPage, error) {
+	if afterID < 0 {
+		return nil, fmt.Errorf("%w: cursor cannot be negative", errs.ErrInvalidInput)
+	}
+
+	_, pageSize = s.normalizePagination(1, pageSize)
+
+	cars, err := s.repo.GetAfter(ctx, afterID, pageSize)
+	if err != nil {
+		logError(ctx, err, "Failed to get cars after ID %d (size %d): %v", afterID, pageSize, err)
+		return nil, fmt.Errorf("failed to get cars: %w", err)
+	}
+
+	page := &model.CarPage{Cars: toCarResponses(cars)}
+
+	// A full page means there may be more cars after the last one
+	if len(cars) == pageSize {
+		nextCursor := cars[len(cars)-1].ID
+		page.NextCursor = &nextCursor
+	}
+
+	return page, nil
+}
+
+// GetRecentCars retrieves the most recently added cars, newest first. The limit
+// defaults to DefaultRecentLimit when unset and is clamped to MaxRecentLimit.
+func (s *carService) GetRecentCars(ctx context.Context, limit int) ([]*model.CarResponse, error) {
+	if limit < 1 {
+		limit = DefaultRecentLimit
+	}
+	limit = min(limit, MaxRecentLimit)
+
+	cars, err := s.repo.GetRecent(ctx, limit)
+	if err != nil {
+		logError(ctx, err, "Failed to get %d recent cars: %v", limit, err)
+		return nil, fmt.Errorf("failed to get recent cars: %w", err)
 	}
 
 	return toCarResponses(cars), nil
 }
 
-// UpdateCar updates an existing car
+// GetRelatedCars retrieves a car along with up to limit other active cars of its
+// brand, those closest in price first
+func (s *carService) GetRelatedCars(ctx context.Context, id int64, limit int) (*model.RelatedCarsResponse, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	if limit < 1 {
+		limit = DefaultRelatedLimit
+	}
+	limit = min(limit, MaxRelatedLimit)
+
+	car, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		logError(ctx, err, "Failed to get car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to get car: %w", err)
+	}
+
+	related, err := s.repo.GetRelated(ctx, car, limit)
+	if err != nil {
+		logError(ctx, err, "Failed to get cars related to car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to get related cars: %w", err)
+	}
+
+	return &model.RelatedCarsResponse{
+		Car:     car.ToResponse(),
+		Related: toCarResponses(related),
+	}, nil
+}
+
+// GetCarExtremes retrieves the cheapest and most expensive active cars, running
+// both lookups concurrently
+func (s *carService) GetCarExtremes(ctx context.Context) (*model.CarExtremesResponse, error) {
+	var cheapest, mostExpensive *model.Car
+
+	// Without any cars there are no extremes, which is not an error. Not-found is
+	// swallowed in each goroutine so it neither cancels nor masks the other query.
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		car, err := s.repo.GetCheapest(gctx)
+		if err != nil && !errors.Is(err, errs.ErrCarNotFound) {
+			return err
+		}
+		cheapest = car
+		return nil
+	})
+	g.Go(func() error {
+		car, err := s.repo.GetMostExpensive(gctx)
+		if err != nil && !errors.Is(err, errs.ErrCarNotFound) {
+			return err
+		}
+		mostExpensive = car
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		logError(ctx, err, "Failed to get cheapest and most expensive cars: %v", err)
+		return nil, fmt.Errorf("failed to get car extremes: %w", err)
+	}
+
+	extremes := &model.CarExtremesResponse{}
+	if cheapest != nil {
+		extremes.Cheapest = cheapest.ToResponse()
+	}
+	if mostExpensive != nil {
+		extremes.MostExpensive = mostExpensive.ToResponse()
+	}
+
+	return extremes, nil
+}
+
+// GetDeletedCars retrieves soft-deleted cars with pagination
+func (s *carService) GetDeletedCars(ctx context.Context, page, pageSize int) ([]*model.DeletedCarResponse, error) {
+	page, pageSize = s.normalizePagination(page, pageSize)
+
+	cars, err := s.repo.GetAllDeleted(ctx, page, pageSize)
+	if err != nil {
+		logError(ctx, err, "Failed to get deleted cars (page %d, size %d): %v", page, pageSize, err)
+		return nil, fmt.Errorf("failed to get deleted cars: %w", err)
+	}
+
+	responses := make([]*model.DeletedCarResponse, 0, len(cars))
+	for _, car := range cars {
+		responses = append(responses, car.ToDeletedResponse())
+	}
+
+	return responses, nil
+}
+
+// FilterCars retrieves cars matching all of the given criteria, with pagination
+func (s *carService) FilterCars(ctx context.Context, filter model.CarFilter) ([]*model.CarResponse, error) {
+	if err := validateCarFilter(filter); err != nil {
+		return nil, err
+	}
+
+	filter.Page, filter.PageSize = s.normalizePagination(filter.Page, filter.PageSize)
+	if filter.Brand != "" {
+		filter.Brand = s.canonicalBrand(filter.Brand)
+	}
+
+	cars, err := s.repo.Filter(ctx, filter)
+	if err != nil {
+		logError(ctx, err, "Failed to filter cars: %v", err)
+		return nil, fmt.Errorf("failed to filter cars: %w", err)
+	}
+
+	return toCarResponses(cars), nil
+}
+
+// StreamCars calls fn for every active car without loading them all into memory
+func (s *carService) StreamCars(ctx context.Context, fn func(car *model.CarResponse) error) error {
+	err := s.repo.Stream(ctx, "", func(car *model.Car) error {
+		return fn(car.ToResponse())
+	})
+	if err != nil {
+		logError(ctx, err, "Failed to stream cars: %v", err)
+		return fmt.Errorf("failed to stream cars: %w", err)
+	}
+
+	return nil
+}
+
+// StreamCarsByBrand calls fn for every active car of a brand, matched case-insensitively,
+// without loading them all into memory
+func (s *carService) StreamCarsByBrand(ctx context.Context, brand string, fn func(car *model.CarResponse) error) error {
+	brand = strings.TrimSpace(brand)
+	if brand == "" {
+		return fmt.Errorf("%w: brand name cannot be empty", errs.ErrInvalidInput)
+	}
+
+	err := s.repo.Stream(ctx, brand, func(car *model.Car) error {
+		return fn(car.ToResponse())
+	})
+	if err != nil {
+		logError(ctx, err, "Failed to stream cars of brand %s: %v", brand, err)
+		return fmt.Errorf("failed to stream cars by brand: %w", err)
+	}
+
+	return nil
+}
+
+// GetRandomFeaturedCar picks a featured car at random, weighted by each car's weight
+func (s *carService) GetRandomFeaturedCar(ctx context.Context) (*model.CarResponse, error) {
+	car, err := s.repo.GetRandomFeatured(ctx)
+	if err != nil {
+		logError(ctx, err, "Failed to get random featured car: %v", err)
+		return nil, fmt.Errorf("failed to get random featured car: %w", err)
+	}
+
+	return car.ToResponse(), nil
+}
+
+// SearchCars retrieves cars whose name, brand or description contains the query, with pagination.
+// At most cfg.SearchMaxResults matches are reachable across all pages; the returned flag
+// reports whether matches were cut off by that cap.
+func (s *carService) SearchCars(ctx context.Context, query string, page, pageSize int) ([]*model.CarResponse, bool, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, false, fmt.Errorf("%w: search query cannot be empty", errs.ErrInvalidInput)
+	}
+
+	page, pageSize = s.normalizePagination(page, pageSize)
+
+	cars, capped, err := s.repo.Search(ctx, query, page, pageSize, s.cfg.SearchMaxResults)
+	if err != nil {
+		logError(ctx, err, "Failed to search cars for %q (page %d, size %d): %v", query, page, pageSize, err)
+		return nil, false, fmt.Errorf("failed to search cars: %w", err)
+	}
+
+	return toCarResponses(cars), capped, nil
+}
+
+// GetCatalogSummary retrieves aggregate figures about the active catalog
+func (s *carService) GetCatalogSummary(ctx context.Context) (*model.CatalogSummaryResponse, error) {
+	summary, err := s.repo.GetSummary(ctx)
+	if err != nil {
+		logError(ctx, err, "Failed to get catalog summary: %v", err)
+		return nil, fmt.Errorf("failed to get catalog summary: %w", err)
+	}
+
+	return summary.ToResponse(), nil
+}
+
+// GetBrandsByValue retrieves the brands ordered by the summed value of their active
+// cars, highest first. A limit of 0 returns every brand.
+func (s *carService) GetBrandsByValue(ctx context.Context, limit int) ([]*model.BrandValueResponse, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("%w: limit cannot be negative", errs.ErrInvalidInput)
+	}
+
+	brands, err := s.repo.GetBrandsByValue(ctx, limit)
+	if err != nil {
+		logError(ctx, err, "Failed to get brands by value: %v", err)
+		return nil, fmt.Errorf("failed to get brands by value: %w", err)
+	}
+
+	return brands, nil
+}
+
+// GetStatsByBrand retrieves price statistics per brand with pagination, brands
+// with the most active cars first
+func (s *carService) GetStatsByBrand(ctx context.Context, page, pageSize int) ([]*model.BrandStatsResponse, error) {
+	page, pageSize = s.normalizePagination(page, pageSize)
+
+	stats, err := s.repo.StatsByBrand(ctx, page, pageSize)
+	if err != nil {
+		logError(ctx, err, "Failed to get stats by brand (page %d, size %d): %v", page, pageSize, err)
+		return nil, fmt.Errorf("failed to get stats by brand: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ListBrands retrieves the distinct brands of the active cars in alphabetical order
+func (s *carService) ListBrands(ctx context.Context) ([]string, error) {
+	brands, err := s.repo.ListBrands(ctx)
+	if err != nil {
+		logError(ctx, err, "Failed to list brands: %v", err)
+		return nil, fmt.Errorf("failed to list brands: %w", err)
+	}
+
+	return brands, nil
+}
+
+// UpdateCar updates an existing car. The request must carry the version it is
+// based on; an outdated version fails with errs.ErrVersionConflict.
 func (s *carService) UpdateCar(ctx context.Context, id int64, req *model.CarRequest) (*model.CarResponse, error) {
 	if id <= 0 {
-		return nil, errors.New("invalid car ID")
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
 	}
 
 	// Validate request
-	if err := validateCarRequest(req); err != nil {
+	if err := s.validateCarRequest(req); err != nil {
 		return nil, err
 	}
 
-	// Check if car exists
-	existingCar, err := s.repo.GetByID(ctx, id)
-	if err != nil {
-		logger.Errorf("Failed to find car with ID %d: %v", id, err)
-		return nil, fmt.Errorf("failed to find car: %v", err)
+	if req.Version < 1 {
+		return nil, fmt.Errorf("%w: version is required to update a car", errs.ErrInvalidInput)
 	}
 
-	// Update car fields
+	s.normalizeRequest(req)
+
+	// The update itself reports a missing car, so there is no need to fetch it first
+	existingCar := &model.Car{ID: id}
 	existingCar.UpdateFromRequest(req)
 
 	// Update car in repository
 	if err := s.repo.Update(ctx, existingCar); err != nil {
-		logger.Errorf("Failed to update car with ID %d: %v", id, err)
-		return nil, fmt.Errorf("failed to update car: %v", err)
+		logError(ctx, err, "Failed to update car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to update car: %w", err)
 	}
 
 	// Get the updated car
-	updatedCar, err := s.repo.GetByID(ctx, id)
+	updatedCar, err := s.repo.GetByID(ctx, id, false)
 	if err != nil {
-		logger.Errorf("Failed to fetch updated car with ID %d: %v", id, err)
-		return nil, fmt.Errorf("failed to fetch updated car: %v", err)
+		logError(ctx, err, "Failed to fetch updated car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch updated car: %w", err)
 	}
 
 	return updatedCar.ToResponse(), nil
 }
 
-// DeleteCar deletes a car by ID
-func (s *carService) DeleteCar(ctx context.Context, id int64) error {
+// PatchCar applies a JSON merge patch (RFC 7386) to an existing car, then
+// validates and saves the result like UpdateCar. Keys absent from the patch keep
+// their current value and a null clears an optional field. The update is based on
+// the version in the patch, else the given version, else the car's current one.
+func (s *carService) PatchCar(ctx context.Context, id int64, patch []byte, version int) (*model.CarResponse, error) {
 	if id <= 0 {
-		return errors.New("invalid car ID")
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	existingCar, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		logError(ctx, err, "Failed to find car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to find car: %w", err)
 	}
 
-	// Check if car exists
-	if _, err := s.repo.GetByID(ctx, id); err != nil {
-		logger.Errorf("Failed to find car with ID %d: %v", id, err)
-		return fmt.Errorf("failed to find car: %v", err)
+	current := existingCar.ToRequest()
+	if version > 0 {
+		current.Version = version
 	}
 
-	// Delete car from repository
+	req, err := applyMergePatch(current, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.UpdateCar(ctx, id, req)
+}
+
+// UpdatePrice changes only the manufacturing value of a car, within the same
+// bounds as a full update
+func (s *carService) UpdatePrice(ctx context.Context, id int64, value model.Money) (*model.CarResponse, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	if err := s.validateManufacturingValue(value); err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	if err := s.repo.UpdatePrice(ctx, id, value); err != nil {
+		logError(ctx, err, "Failed to update price of car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to update car price: %w", err)
+	}
+
+	updatedCar, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		logError(ctx, err, "Failed to fetch updated car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch updated car: %w", err)
+	}
+
+	return updatedCar.ToResponse(), nil
+}
+
+// UpdateBrandPrices applies a percentage change to the prices of all active cars
+// of a brand. The change is rejected as a whole when any resulting price would
+// fall outside the configured bounds.
+func (s *carService) UpdateBrandPrices(ctx context.Context, brand string, percentChange float64) (*model.BrandPriceUpdateResponse, error) {
+	if err := validatePriceAdjustment(&model.PriceAdjustmentRequest{Brand: brand, Percent: percentChange}); err != nil {
+		return nil, err
+	}
+
+	brand = s.canonicalBrand(brand)
+	minValue := model.MoneyFromFloat(s.cfg.MinManufacturingValue)
+	maxValue := model.MoneyFromFloat(s.cfg.MaxManufacturingValue)
+
+	ids, err := s.repo.AdjustBrandPrices(ctx, brand, percentChange, minValue, maxValue)
+	if err != nil {
+		logError(ctx, err, "Failed to update prices of brand %s by %g%%: %v", brand, percentChange, err)
+		return nil, fmt.Errorf("failed to update brand prices: %w", err)
+	}
+
+	return &model.BrandPriceUpdateResponse{
+		Brand:         brand,
+		PercentChange: percentChange,
+		Updated:       len(ids),
+		IDs:           ids,
+	}, nil
+}
+
+// UpsertCarByName updates the car with the given name, or creates it when there is
+// none, and reports whether it was created. Without a version in the request the
+// update applies on top of whatever version is current.
+func (s *carService) UpsertCarByName(ctx context.Context, name string, req *model.CarRequest) (*model.CarResponse, bool, error) {
+	if err := s.validateCarRequest(req); err != nil {
+		return nil, false, err
+	}
+
+	if req.Name != name {
+		return nil, false, fmt.Errorf("%w: name in the body must match the name in the path", errs.ErrInvalidInput)
+	}
+
+	s.normalizeRequest(req)
+
+	// A concurrent upsert may create the car between the lookup and the insert;
+	// the insert then fails as a duplicate and the second pass updates instead
+	for attempt := 0; attempt < 2; attempt++ {
+		existingCar, err := s.findByName(ctx, name, req.Brand, false)
+		if err == nil {
+			version := req.Version
+			if version == 0 {
+				version = existingCar.Version
+			}
+			existingCar.UpdateFromRequest(req)
+			existingCar.Version = version
+
+			if err := s.repo.Update(ctx, existingCar); err != nil {
+				logError(ctx, err, "Failed to update car with name %s: %v", name, err)
+				return nil, false, fmt.Errorf("failed to update car: %w", err)
+			}
+
+			updatedCar, err := s.repo.GetByID(ctx, existingCar.ID, false)
+			if err != nil {
+				logError(ctx, err, "Failed to fetch updated car with ID %d: %v", existingCar.ID, err)
+				return nil, false, fmt.Errorf("failed to fetch updated car: %w", err)
+			}
+
+			return updatedCar.ToResponse(), false, nil
+		}
+
+		if !errors.Is(err, errs.ErrCarNotFound) {
+			logError(ctx, err, "Failed to get car by name %s: %v", name, err)
+			return nil, false, fmt.Errorf("failed to get car by name: %w", err)
+		}
+
+		id, err := s.repo.Create(ctx, req.ToModel())
+		if errors.Is(err, errs.ErrDuplicateCar) {
+			continue
+		}
+		if err != nil {
+			logError(ctx, err, "Failed to create car with name %s: %v", name, err)
+			return nil, false, fmt.Errorf("failed to create car: %w", err)
+		}
+
+		createdCar, err := s.repo.GetByID(ctx, id, false)
+		if err != nil {
+			logError(ctx, err, "Failed to fetch created car: %v", err)
+			return nil, false, fmt.Errorf("failed to fetch created car: %w", err)
+		}
+
+		return createdCar.ToResponse(), true, nil
+	}
+
+	return nil, false, fmt.Errorf("car with name %s: %w", name, errs.ErrDuplicateCar)
+}
+
+// DeleteCar deletes a car by ID
+func (s *carService) DeleteCar(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	// Delete car from repository; a missing car shows up as no row being affected
 	if err := s.repo.Delete(ctx, id); err != nil {
-		logger.Errorf("Failed to delete car with ID %d: %v", id, err)
-		return fmt.Errorf("failed to delete car: %v", err)
+		logError(ctx, err, "Failed to delete car with ID %d: %v", id, err)
+		return fmt.Errorf("failed to delete car: %w", err)
 	}
 
 	return nil
 }
 
-// validateCarRequest validates the car request
-func validateCarRequest(req *model.CarRequest) error {
-	if req == nil {
-		return errors.New("request cannot be nil")
+// DeleteCars deletes several cars at once. IDs that do not match an active car
+// are reported back instead of failing the whole request.
+func (s *carService) DeleteCars(ctx context.Context, ids []int64) (*model.DeleteCarsResponse, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: at least one car ID is required", errs.ErrInvalidInput)
 	}
 
-	if req.Name == "" {
-		return errors.New("car name is required")
+	if len(ids) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: cannot delete more than %d cars at once", errs.ErrInvalidInput, MaxBatchSize)
 	}
 
-	if req.Brand == "" {
-		return errors.New("car brand is required")
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, fmt.Errorf("%w: invalid car ID %d", errs.ErrInvalidInput, id)
+		}
 	}
 
-	if req.ManufacturingValue <= 0 {
-		return errors.New("manufacturing value must be greater than 0")
+	deleted, err := s.repo.DeleteBatch(ctx, ids)
+	if err != nil {
+		logError(ctx, err, "Failed to delete %d cars: %v", len(ids), err)
+		return nil, fmt.Errorf("failed to delete cars: %w", err)
 	}
 
-	if req.ManufacturingValue >= 15000000 {
-		return errors.New("manufacturing value must be less than 15,000,000")
+	deletedSet := make(map[int64]bool, len(deleted))
+	for _, id := range deleted {
+		deletedSet[id] = true
 	}
 
-	return nil
+	notFound := make([]int64, 0)
+	for _, id := range ids {
+		if !deletedSet[id] {
+			notFound = append(notFound, id)
+			// Report an ID listed twice only once
+			deletedSet[id] = true
+		}
+	}
+
+	return &model.DeleteCarsResponse{Deleted: len(deleted), NotFound: notFound}, nil
+}
+
+// MergeCars merges a duplicate car into a primary one and returns the resulting primary car
+func (s *carService) MergeCars(ctx context.Context, req *model.MergeCarsRequest) (*model.CarResponse, error) {
+	if req.PrimaryID <= 0 || req.DuplicateID <= 0 {
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	if req.PrimaryID == req.DuplicateID {
+		return nil, fmt.Errorf("%w: a car cannot be merged into itself", errs.ErrInvalidInput)
+	}
+
+	if err := s.repo.Merge(ctx, req.PrimaryID, req.DuplicateID, actorFromContext(ctx)); err != nil {
+		logError(ctx, err, "Failed to merge car with ID %d into car with ID %d: %v", req.DuplicateID, req.PrimaryID, err)
+		return nil, fmt.Errorf("failed to merge cars: %w", err)
+	}
+
+	primaryCar, err := s.repo.GetByID(ctx, req.PrimaryID, false)
+	if err != nil {
+		logError(ctx, err, "Failed to fetch merged car with ID %d: %v", req.PrimaryID, err)
+		return nil, fmt.Errorf("failed to fetch merged car: %w", err)
+	}
+
+	return primaryCar.ToResponse(), nil
+}
+
+// ScheduleCarDeletion schedules a car to be deleted at a future time
+func (s *carService) ScheduleCarDeletion(ctx context.Context, id int64, at time.Time) (*model.CarResponse, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	if !at.After(time.Now()) {
+		return nil, fmt.Errorf("%w: scheduled deletion time must be in the future", errs.ErrInvalidInput)
+	}
+
+	if err := s.repo.ScheduleDelete(ctx, id, at); err != nil {
+		logError(ctx, err, "Failed to schedule deletion of car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to schedule car deletion: %w", err)
+	}
+
+	car, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		logError(ctx, err, "Failed to fetch scheduled car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch scheduled car: %w", err)
+	}
+
+	return car.ToResponse(), nil
+}
+
+// CancelCarDeletion cancels a pending scheduled deletion of a car
+func (s *carService) CancelCarDeletion(ctx context.Context, id int64) (*model.CarResponse, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid car ID", errs.ErrInvalidInput)
+	}
+
+	if err := s.repo.CancelScheduledDelete(ctx, id); err != nil {
+		logError(ctx, err, "Failed to cancel scheduled deletion of car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to cancel car deletion: %w", err)
+	}
+
+	car, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		logError(ctx, err, "Failed to fetch car with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch car: %w", err)
+	}
+
+	return car.ToResponse(), nil
+}
+
+// ProcessScheduledDeletions soft deletes every car whose scheduled deletion
+// time has passed, returning the IDs of the deleted cars
+func (s *carService) ProcessScheduledDeletions(ctx context.Context) ([]int64, error) {
+	deleted, err := s.repo.DeleteScheduled(ctx, time.Now())
+	if err != nil {
+		logError(ctx, err, "Failed to process scheduled deletions: %v", err)
+		return nil, fmt.Errorf("failed to process scheduled deletions: %w", err)
+	}
+
+	if len(deleted) > 0 {
+		logger.WithRequestID(ctx).Infof("Deleted %d cars with a passed scheduled deletion time", len(deleted))
+	}
+
+	return deleted, nil
+}
+
+// PurgeDeletedCars permanently removes the cars soft deleted longer ago than the
+// configured retention, returning their IDs
+func (s *carService) PurgeDeletedCars(ctx context.Context) ([]int64, error) {
+	before := time.Now().AddDate(0, 0, -s.cfg.PurgeRetentionDays)
+
+	purged, err := s.repo.PurgeDeletedBefore(ctx, before)
+	if err != nil {
+		logError(ctx, err, "Failed to purge cars deleted before %s: %v", before.Format(time.RFC3339), err)
+		return nil, fmt.Errorf("failed to purge deleted cars: %w", err)
+	}
+
+	if len(purged) > 0 {
+		logger.WithRequestID(ctx).Infof("Purged %d cars deleted more than %d days ago", len(purged), s.cfg.PurgeRetentionDays)
+	}
+
+	return purged, nil
+}
+
+// PurgeIdempotencyKeys removes the idempotency keys older than
+// repository.IdempotencyKeyTTL, returning how many were removed
+func (s *carService) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	expiredBefore := time.Now().Add(-repository.IdempotencyKeyTTL)
+
+	purged, err := s.repo.PurgeIdempotencyKeys(ctx, expiredBefore)
+	if err != nil {
+		logError(ctx, err, "Failed to purge idempotency keys used before %s: %v", expiredBefore.Format(time.RFC3339), err)
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	if purged > 0 {
+		logger.WithRequestID(ctx).Infof("Purged %d expired idempotency keys", purged)
+	}
+
+	return purged, nil
+}
+
+// normalizeRequest tidies up request fields before they are persisted
+func (s *carService) normalizeRequest(req *model.CarRequest) {
+	if s.cfg.NormalizeDescription && req.Description != nil {
+		desc := normalizeDescription(*req.Description)
+		req.Description = &desc
+	}
+
+	req.Brand = s.canonicalBrand(req.Brand)
+}
+
+// canonicalBrand returns the form a brand is stored and looked up in: the
+// brand as given, or its normalized form when brand normalization is enabled.
+// Either way, brand lookups ignore case, so cars saved before enabling it are
+// still found.
+func (s *carService) canonicalBrand(brand string) string {
+	if !s.cfg.NormalizeBrands {
+		return brand
+	}
+
+	return normalizeBrand(brand)
+}
+
+// withTx runs fn in a transaction when the service has a TxManager, and
+// directly on its repository otherwise
+func (s *carService) withTx(ctx context.Context, fn func(repo repository.CarRepository) error) error {
+	if s.txm == nil {
+		return fn(s.repo)
+	}
+
+	return s.txm.WithTx(ctx, fn)
+}
+
+// findByName retrieves the car a car with the given name and brand would clash
+// with: the one with the same name, or with the same name and brand when names
+// are only unique per brand
+func (s *carService) findByName(ctx context.Context, name, brand string, includeDeleted bool) (*model.Car, error) {
+	if s.cfg.UniqueByNameBrand {
+		return s.repo.GetByNameAndBrand(ctx, name, brand, includeDeleted)
+	}
+
+	return s.repo.GetByName(ctx, name, includeDeleted)
+}
+
+// nameKey identifies a car by what has to be unique about it, for messages and
+// to spot duplicates within a batch
+func (s *carService) nameKey(name, brand string) string {
+	if s.cfg.UniqueByNameBrand {
+		return fmt.Sprintf("%s (%s)", name, brand)
+	}
+
+	return name
+}
+
+// normalizeBrand trims a brand, collapses runs of whitespace and title cases
+// every word, e.g. " land  ROVER " becomes "Land Rover". Words of up to
+// maxBrandAcronymLength letters given in upper case are taken for acronyms and
+// kept, so BMW stays BMW.
+func normalizeBrand(brand string) string {
+	words := strings.Fields(brand)
+	for i, word := range words {
+		if utf8.RuneCountInString(word) <= maxBrandAcronymLength && word == strings.ToUpper(word) {
+			continue
+		}
+
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// maxBrandAcronymLength is the length of the longest upper case brand word that
+// normalizeBrand keeps as an acronym
+const maxBrandAcronymLength = 3
+
+// normalizeDescription trims a description and collapses runs of whitespace
+// within each line into a single space, keeping line breaks intact
+func normalizeDescription(desc string) string {
+	lines := strings.Split(strings.ReplaceAll(desc, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// PreviewPriceAdjustment shows, one page at a time and without writing anything, the
+// prices the brand's cars would get from a percentage price adjustment
+func (s *carService) PreviewPriceAdjustment(ctx context.Context, req *model.PriceAdjustmentRequest, page, pageSize int) (*model.PriceAdjustmentPreviewResponse, error) {
+	if err := validatePriceAdjustment(req); err != nil {
+		return nil, err
+	}
+
+	page, pageSize = s.normalizePagination(page, pageSize)
+	req.Brand = s.canonicalBrand(req.Brand)
+
+	cars, err := s.repo.Filter(ctx, model.CarFilter{Brand: req.Brand, Page: page, PageSize: pageSize})
+	if err != nil {
+		logError(ctx, err, "Failed to get cars of brand %s for price adjustment preview: %v", req.Brand, err)
+		return nil, fmt.Errorf("failed to preview price adjustment: %w", err)
+	}
+
+	// The bounds are those UpdateBrandPrices enforces
+	minValue := model.MoneyFromFloat(s.cfg.MinManufacturingValue)
+	maxValue := model.MoneyFromFloat(s.cfg.MaxManufacturingValue)
+
+	items := make([]*model.PriceAdjustmentPreviewItem, 0, len(cars))
+	for _, car := range cars {
+		proposed := car.ManufacturingValue.AdjustedBy(req.Percent)
+		items = append(items, &model.PriceAdjustmentPreviewItem{
+			ID:            car.ID,
+			Name:          car.Name,
+			CurrentPrice:  car.ManufacturingValue,
+			ProposedPrice: proposed,
+			BelowMin:      proposed <= minValue,
+			ExceedsMax:    proposed >= maxValue,
+		})
+	}
+
+	return &model.PriceAdjustmentPreviewResponse{
+		Brand:    req.Brand,
+		Percent:  req.Percent,
+		Page:     page,
+		PageSize: pageSize,
+		Cars:     items,
+	}, nil
+}
+
+// normalizePagination defaults an unset page and page size, and clamps page
+// sizes above the configured maximum to it
+func (s *carService) normalizePagination(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+
+	if pageSize < 1 {
+		pageSize = s.cfg.DefaultPageSize
+	}
+
+	return page, min(pageSize, s.cfg.MaxPageSize)
+}
+
+// isCanceled reports whether err comes from the request being canceled or timing out
+// rather than from a failure of the service itself
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// logError logs a failed operation. Canceled and timed out requests are only
+// logged at debug level since there is nothing to fix on our side.
+func logError(ctx context.Context, err error, format string, args ...interface{}) {
+	if isCanceled(err) || ctx.Err() != nil {
+		logger.WithRequestID(ctx).Debugf(format, args...)
+		return
+	}
+
+	logger.WithRequestID(ctx).Errorf(format, args...)
 }
 
 // toCarResponses converts a slice of Car to a slice of CarResponse
@@ -0,0 +1,778 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/username/go-car-service/internal/config"
+	"github.com/username/go-car-service/internal/errs"
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/internal/repository"
+	"github.com/username/go-car-service/pkg/logger"
+)
+
+// TestMain runs the tests from the repository root, where the migrations live
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	logger.SetOutput(io.Discard)
+
+	if err := os.Chdir("../.."); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to change to the repository root: %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestService creates a car service over a fresh in-memory store, with the
+// default configuration adjusted by configure
+func newTestService(t *testing.T, configure func(cfg *config.Config)) (CarService, repository.Store) {
+	t.Helper()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	store, err := repository.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.SetNameUniqueness(context.Background(), cfg.UniqueByNameBrand); err != nil {
+		t.Fatalf("failed to set name uniqueness: %v", err)
+	}
+
+	return NewCarService(store.Cars(), store, cfg), store
+}
+
+// carRequest builds a valid request for a car
+func carRequest(name, brand string) *model.CarRequest {
+	return &model.CarRequest{Name: name, Brand: brand, ManufacturingValue: model.MoneyFromFloat(25000), Year: 2020}
+}
+
+// mustCreateCar creates a car through the service, failing the test on error
+func mustCreateCar(t *testing.T, svc CarService, req *model.CarRequest) *model.CarResponse {
+	t.Helper()
+
+	car, _, err := svc.CreateCar(context.Background(), req, IdempotencyKey{})
+	if err != nil {
+		t.Fatalf("CreateCar(%s, %s) error = %v", req.Name, req.Brand, err)
+	}
+
+	return car
+}
+
+func TestNameUniquenessModes(t *testing.T) {
+	tests := []struct {
+		name           string
+		byBrand        bool
+		wantOtherBrand error
+		wantNoBrand    error
+		wantByBrand    string
+	}{
+		{name: "unique names", byBrand: false, wantOtherBrand: errs.ErrDuplicateCar, wantNoBrand: nil, wantByBrand: ""},
+		{name: "unique per brand", byBrand: true, wantOtherBrand: nil, wantNoBrand: errs.ErrInvalidInput, wantByBrand: "Toyota"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t, func(cfg *config.Config) { cfg.UniqueByNameBrand = tt.byBrand })
+
+			if _, _, err := svc.CreateCar(ctx, carRequest("Civic", "Honda"), IdempotencyKey{}); err != nil {
+				t.Fatalf("CreateCar(Civic, Honda) error = %v", err)
+			}
+
+			if _, _, err := svc.CreateCar(ctx, carRequest("Civic", "Toyota"), IdempotencyKey{}); !errors.Is(err, tt.wantOtherBrand) {
+				t.Errorf("CreateCar(Civic, Toyota) error = %v, want %v", err, tt.wantOtherBrand)
+			}
+
+			// Brands differing only in case are the same brand in both modes
+			if _, _, err := svc.CreateCar(ctx, carRequest("Civic", "HONDA"), IdempotencyKey{}); !errors.Is(err, errs.ErrDuplicateCar) {
+				t.Errorf("CreateCar(Civic, HONDA) error = %v, want ErrDuplicateCar", err)
+			}
+
+			// The unique index backs the service check
+			_, err := store.Cars().Create(ctx, &model.Car{Name: "Civic", Brand: "honda", ManufacturingValue: model.MoneyFromFloat(25000), Weight: 1})
+			if !errors.Is(err, errs.ErrDuplicateCar) {
+				t.Errorf("repository Create(Civic, honda) error = %v, want ErrDuplicateCar", err)
+			}
+
+			if _, err := svc.GetCarByName(ctx, "Civic", ""); !errors.Is(err, tt.wantNoBrand) {
+				t.Errorf("GetCarByName(Civic) error = %v, want %v", err, tt.wantNoBrand)
+			}
+
+			car, err := svc.GetCarByName(ctx, "Civic", " toyota ")
+			switch {
+			case tt.wantByBrand == "" && !errors.Is(err, errs.ErrCarNotFound):
+				t.Errorf("GetCarByName(Civic, toyota) error = %v, want ErrCarNotFound", err)
+			case tt.wantByBrand != "" && (err != nil || car.Brand != tt.wantByBrand):
+				t.Errorf("GetCarByName(Civic, toyota) = %+v, %v; want the %s car", car, err, tt.wantByBrand)
+			}
+		})
+	}
+}
+
+func TestSetNameUniquenessBackToUniqueNames(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(t, func(cfg *config.Config) { cfg.UniqueByNameBrand = true })
+
+	for _, brand := range []string{"Honda", "Toyota"} {
+		if _, _, err := svc.CreateCar(ctx, carRequest("Civic", brand), IdempotencyKey{}); err != nil {
+			t.Fatalf("CreateCar(Civic, %s) error = %v", brand, err)
+		}
+	}
+
+	// Two brands share a name, so names can't be made unique on their own
+	if err := store.SetNameUniqueness(ctx, false); err == nil {
+		t.Fatal("SetNameUniqueness(false) succeeded while two brands share a name")
+	}
+
+	// The per-brand index is left in place
+	_, err := store.Cars().Create(ctx, &model.Car{Name: "Civic", Brand: "HONDA", ManufacturingValue: model.MoneyFromFloat(25000), Weight: 1})
+	if !errors.Is(err, errs.ErrDuplicateCar) {
+		t.Errorf("repository Create(Civic, HONDA) error = %v, want ErrDuplicateCar", err)
+	}
+}
+
+func TestScheduleCarDeletion(t *testing.T) {
+	tests := []struct {
+		name    string
+		at      time.Time
+		wantErr error
+	}{
+		{name: "future time", at: time.Now().Add(time.Hour)},
+		{name: "past time", at: time.Now().Add(-time.Hour), wantErr: errs.ErrInvalidInput},
+		{name: "now", at: time.Now(), wantErr: errs.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t, nil)
+			car := mustCreateCar(t, svc, carRequest("Civic", "Honda"))
+
+			scheduled, err := svc.ScheduleCarDeletion(context.Background(), car.ID, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScheduleCarDeletion() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && scheduled.DeleteScheduledAt == nil {
+				t.Error("ScheduleCarDeletion() returned a car without a scheduled deletion time")
+			}
+
+			// The car stays visible until the scheduled time
+			if _, err := svc.GetCarByID(context.Background(), car.ID); err != nil {
+				t.Errorf("GetCarByID() after scheduling error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCancelCarDeletion(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, nil)
+	car := mustCreateCar(t, svc, carRequest("Civic", "Honda"))
+
+	if _, err := svc.ScheduleCarDeletion(ctx, car.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ScheduleCarDeletion() error = %v", err)
+	}
+
+	cancelled, err := svc.CancelCarDeletion(ctx, car.ID)
+	if err != nil {
+		t.Fatalf("CancelCarDeletion() error = %v", err)
+	}
+	if cancelled.DeleteScheduledAt != nil {
+		t.Errorf("CancelCarDeletion() left the deletion scheduled at %s", *cancelled.DeleteScheduledAt)
+	}
+}
+
+func TestProcessScheduledDeletions(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(t, nil)
+	due := mustCreateCar(t, svc, carRequest("Civic", "Honda"))
+	later := mustCreateCar(t, svc, carRequest("Accord", "Honda"))
+	kept := mustCreateCar(t, svc, carRequest("Fit", "Honda"))
+
+	// A deletion whose time has passed, as if scheduled earlier
+	if err := store.Cars().ScheduleDelete(ctx, due.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("ScheduleDelete() error = %v", err)
+	}
+	if _, err := svc.ScheduleCarDeletion(ctx, later.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ScheduleCarDeletion() error = %v", err)
+	}
+
+	deleted, err := svc.ProcessScheduledDeletions(ctx)
+	if err != nil {
+		t.Fatalf("ProcessScheduledDeletions() error = %v", err)
+	}
+	if fmt.Sprint(deleted) != fmt.Sprint([]int64{due.ID}) {
+		t.Errorf("ProcessScheduledDeletions() = %v, want [%d]", deleted, due.ID)
+	}
+
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "due car is deleted", id: due.ID, wantErr: errs.ErrCarNotFound},
+		{name: "car scheduled later is kept", id: later.ID},
+		{name: "unscheduled car is kept", id: kept.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.GetCarByID(ctx, tt.id); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetCarByID(%d) error = %v, want %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetCatalogSummary(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty catalog", func(t *testing.T) {
+		svc, _ := newTestService(t, nil)
+
+		summary, err := svc.GetCatalogSummary(ctx)
+		if err != nil {
+			t.Fatalf("GetCatalogSummary() error = %v", err)
+		}
+		if summary.TotalCars != 0 || summary.TotalBrands != 0 || summary.TotalValue != 0 || summary.OldestCreatedAt != nil || summary.NewestCreatedAt != nil {
+			t.Errorf("GetCatalogSummary() = %+v, want zeros and nulls", summary)
+		}
+	})
+
+	t.Run("seeded catalog", func(t *testing.T) {
+		svc, _ := newTestService(t, nil)
+		oldest := mustCreateCar(t, svc, &model.CarRequest{Name: "Civic", Brand: "Honda", ManufacturingValue: model.MoneyFromFloat(20000.50)})
+		mustCreateCar(t, svc, &model.CarRequest{Name: "Corolla", Brand: "Toyota", ManufacturingValue: model.MoneyFromFloat(18000)})
+		newest := mustCreateCar(t, svc, &model.CarRequest{Name: "Accord", Brand: "Honda", ManufacturingValue: model.MoneyFromFloat(30000.25)})
+		deleted := mustCreateCar(t, svc, &model.CarRequest{Name: "Model 3", Brand: "Tesla", ManufacturingValue: model.MoneyFromFloat(40000)})
+		if err := svc.DeleteCar(ctx, deleted.ID); err != nil {
+			t.Fatalf("DeleteCar() error = %v", err)
+		}
+
+		summary, err := svc.GetCatalogSummary(ctx)
+		if err != nil {
+			t.Fatalf("GetCatalogSummary() error = %v", err)
+		}
+
+		tests := []struct {
+			field string
+			got   interface{}
+			want  interface{}
+		}{
+			{field: "total_cars", got: summary.TotalCars, want: int64(3)},
+			{field: "total_brands", got: summary.TotalBrands, want: int64(2)},
+			{field: "total_value", got: summary.TotalValue.String(), want: "68000.75"},
+			{field: "oldest_created_at", got: derefString(summary.OldestCreatedAt), want: oldest.CreatedAt},
+			{field: "newest_created_at", got: derefString(summary.NewestCreatedAt), want: newest.CreatedAt},
+		}
+
+		for _, tt := range tests {
+			if tt.got != tt.want {
+				t.Errorf("GetCatalogSummary() %s = %v, want %v", tt.field, tt.got, tt.want)
+			}
+		}
+	})
+}
+
+// derefString returns the string s points to, or "" for nil
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func TestNormalizeDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "tidy", in: "Low mileage", want: "Low mileage"},
+		{name: "surrounding whitespace", in: "  Low mileage \t", want: "Low mileage"},
+		{name: "doubled spaces and tabs", in: "Low  \t mileage", want: "Low mileage"},
+		{name: "newlines kept", in: "Low mileage\nOne owner", want: "Low mileage\nOne owner"},
+		{name: "messy lines", in: "\n  Low   mileage  \r\n  One  owner \n", want: "Low mileage\nOne owner"},
+		{name: "whitespace only", in: " \t\n ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeDescription(tt.in); got != tt.want {
+				t.Errorf("normalizeDescription(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescriptionNormalizationOnWrite(t *testing.T) {
+	messy := "  Low   mileage \n  One\towner  "
+	tests := []struct {
+		name      string
+		normalize bool
+		want      string
+	}{
+		{name: "enabled", normalize: true, want: "Low mileage\nOne owner"},
+		{name: "disabled", normalize: false, want: messy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t, func(cfg *config.Config) { cfg.NormalizeDescription = tt.normalize })
+
+			req := carRequest("Civic", "Honda")
+			req.Description = &messy
+			created := mustCreateCar(t, svc, req)
+
+			update := carRequest("Civic", "Honda")
+			update.Description = &messy
+			update.Version = created.Version
+			updated, err := svc.UpdateCar(ctx, created.ID, update)
+			if err != nil {
+				t.Fatalf("UpdateCar() error = %v", err)
+			}
+
+			for _, id := range []int64{created.ID, updated.ID} {
+				stored, err := store.Cars().GetByID(ctx, id, false)
+				if err != nil {
+					t.Fatalf("GetByID() error = %v", err)
+				}
+				if stored.Description.String != tt.want {
+					t.Errorf("stored description = %q, want %q", stored.Description.String, tt.want)
+				}
+			}
+			if created.Description == nil || *created.Description != tt.want {
+				t.Errorf("created description = %v, want %q", created.Description, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchCars(t *testing.T) {
+	svc, _ := newTestService(t, func(cfg *config.Config) {
+		cfg.DefaultPageSize = 2
+		cfg.MaxPageSize = 3
+	})
+	for _, name := range []string{"Civic", "Civic Type R", "Civic Si", "Civic Hybrid", "Accord"} {
+		mustCreateCar(t, svc, carRequest(name, "Honda"))
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		page      int
+		pageSize  int
+		wantCount int
+		wantErr   error
+	}{
+		{name: "empty query", query: "", wantErr: errs.ErrInvalidInput},
+		{name: "blank query", query: " \t ", wantErr: errs.ErrInvalidInput},
+		{name: "default page size", query: "civic", wantCount: 2},
+		{name: "page size clamped", query: "civic", pageSize: 50, wantCount: 3},
+		{name: "second page", query: "civic", page: 2, pageSize: 3, wantCount: 1},
+		{name: "past the last page", query: "civic", page: 3, pageSize: 3, wantCount: 0},
+		{name: "query trimmed", query: "  accord  ", wantCount: 1},
+		{name: "no match", query: "corolla", wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cars, _, err := svc.SearchCars(context.Background(), tt.query, tt.page, tt.pageSize)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SearchCars(%q) error = %v, want %v", tt.query, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SearchCars(%q) error = %v", tt.query, err)
+			}
+			if len(cars) != tt.wantCount {
+				t.Errorf("SearchCars(%q, %d, %d) returned %d cars, want %d", tt.query, tt.page, tt.pageSize, len(cars), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestMergeCars(t *testing.T) {
+	description := "One owner"
+	tests := []struct {
+		name            string
+		self            bool
+		missing         bool
+		wantErr         error
+		wantDescription string
+	}{
+		{name: "merged", wantDescription: description},
+		{name: "into itself", self: true, wantErr: errs.ErrInvalidInput},
+		{name: "missing duplicate", missing: true, wantErr: errs.ErrCarNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithActor(context.Background(), "admin")
+			svc, store := newTestService(t, nil)
+			primary := mustCreateCar(t, svc, carRequest("Civic", "Honda"))
+			duplicateReq := carRequest("Civic LX", "Honda")
+			duplicateReq.Description = &description
+			duplicate := mustCreateCar(t, svc, duplicateReq)
+
+			req := &model.MergeCarsRequest{PrimaryID: primary.ID, DuplicateID: duplicate.ID}
+			if tt.self {
+				req.DuplicateID = primary.ID
+			}
+			if tt.missing {
+				req.DuplicateID = duplicate.ID + 100
+			}
+
+			merged, err := svc.MergeCars(ctx, req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("MergeCars() error = %v, want %v", err, tt.wantErr)
+				}
+				if _, err := svc.GetCarByID(ctx, primary.ID); err != nil {
+					t.Errorf("primary car after a failed merge: GetCarByID() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeCars() error = %v", err)
+			}
+
+			if merged.ID != primary.ID || derefString(merged.Description) != tt.wantDescription {
+				t.Errorf("merged car = %d with description %q, want %d with %q", merged.ID, derefString(merged.Description), primary.ID, tt.wantDescription)
+			}
+			if _, err := svc.GetCarByID(ctx, duplicate.ID); !errors.Is(err, errs.ErrCarNotFound) {
+				t.Errorf("duplicate car after merge: GetCarByID() error = %v, want %v", err, errs.ErrCarNotFound)
+			}
+
+			events, err := store.Audit().List(ctx, model.AuditEventFilter{CarID: primary.ID, EventType: model.AuditEventMerged, Limit: 10})
+			if err != nil {
+				t.Fatalf("failed to list merge events: %v", err)
+			}
+			if len(events) != 1 || events[0].Actor.String != "admin" {
+				t.Errorf("merge events = %+v, want one by admin", events)
+			}
+		})
+	}
+}
+
+func TestGetBrandsByValue(t *testing.T) {
+	svc, _ := newTestService(t, nil)
+	seed := []struct {
+		name, brand string
+		value       float64
+	}{
+		{"Civic", "Honda", 25000},
+		{"Accord", "Honda", 30000.50},
+		{"Corolla", "Toyota", 60000},
+		{"Golf", "Volkswagen", 20000},
+		{"Polo", "Volkswagen", 15000},
+		{"Mustang", "Ford", 90000},
+	}
+	ctx := context.Background()
+	for _, car := range seed {
+		req := carRequest(car.name, car.brand)
+		req.ManufacturingValue = model.MoneyFromFloat(car.value)
+		created := mustCreateCar(t, svc, req)
+		if car.name == "Mustang" {
+			// Deleted cars don't count towards their brand
+			if err := svc.DeleteCar(ctx, created.ID); err != nil {
+				t.Fatalf("DeleteCar() error = %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		limit   int
+		want    []string
+		wantErr error
+	}{
+		{name: "all brands", limit: 0, want: []string{"Toyota:1:60000.00", "Honda:2:55000.50", "Volkswagen:2:35000.00"}},
+		{name: "limited", limit: 2, want: []string{"Toyota:1:60000.00", "Honda:2:55000.50"}},
+		{name: "limit above the brand count", limit: 10, want: []string{"Toyota:1:60000.00", "Honda:2:55000.50", "Volkswagen:2:35000.00"}},
+		{name: "negative limit", limit: -1, wantErr: errs.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brands, err := svc.GetBrandsByValue(ctx, tt.limit)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetBrandsByValue(%d) error = %v, want %v", tt.limit, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetBrandsByValue(%d) error = %v", tt.limit, err)
+			}
+
+			got := make([]string, 0, len(brands))
+			for _, brand := range brands {
+				got = append(got, fmt.Sprintf("%s:%d:%s", brand.Brand, brand.Count, brand.TotalValue))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetBrandsByValue(%d) = %v, want %v", tt.limit, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("empty catalog", func(t *testing.T) {
+		empty, _ := newTestService(t, nil)
+		brands, err := empty.GetBrandsByValue(ctx, 0)
+		if err != nil {
+			t.Fatalf("GetBrandsByValue() error = %v", err)
+		}
+		if brands == nil || len(brands) != 0 {
+			t.Errorf("GetBrandsByValue() = %v, want an empty, non-nil slice", brands)
+		}
+	})
+}
+
+func TestNormalizePagination(t *testing.T) {
+	s := &carService{cfg: &config.Config{DefaultPageSize: 10, MaxPageSize: 100}}
+
+	tests := []struct {
+		name         string
+		page         int
+		pageSize     int
+		wantPage     int
+		wantPageSize int
+	}{
+		{name: "within bounds", page: 3, pageSize: 20, wantPage: 3, wantPageSize: 20},
+		{name: "defaults", page: 0, pageSize: 0, wantPage: 1, wantPageSize: 10},
+		{name: "negative", page: -2, pageSize: -5, wantPage: 1, wantPageSize: 10},
+		{name: "at the maximum", page: 1, pageSize: 100, wantPage: 1, wantPageSize: 100},
+		{name: "clamped to the maximum", page: 1, pageSize: 1000, wantPage: 1, wantPageSize: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, pageSize := s.normalizePagination(tt.page, tt.pageSize)
+			if page != tt.wantPage || pageSize != tt.wantPageSize {
+				t.Errorf("normalizePagination(%d, %d) = (%d, %d), want (%d, %d)", tt.page, tt.pageSize, page, pageSize, tt.wantPage, tt.wantPageSize)
+			}
+		})
+	}
+}
+
+func TestCreateCarWithNameOfDeletedCar(t *testing.T) {
+	tests := []struct {
+		name        string
+		deleteFirst bool
+		wantErr     error
+	}{
+		{name: "deleted car's name is reused", deleteFirst: true},
+		{name: "active car's name blocks", deleteFirst: false, wantErr: errs.ErrDuplicateCar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t, nil)
+			original := mustCreateCar(t, svc, carRequest("Civic", "Honda"))
+			if tt.deleteFirst {
+				if err := svc.DeleteCar(ctx, original.ID); err != nil {
+					t.Fatalf("DeleteCar() error = %v", err)
+				}
+			}
+
+			req := carRequest("Civic", "Honda")
+			req.ManufacturingValue = model.MoneyFromFloat(27000)
+			created, _, err := svc.CreateCar(ctx, req, IdempotencyKey{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateCar() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateCar() error = %v", err)
+			}
+			if created.ID == original.ID {
+				t.Fatalf("CreateCar() restored car %d instead of creating a new one", original.ID)
+			}
+
+			active, err := store.Cars().GetByName(ctx, "Civic", false)
+			if err != nil || active.ID != created.ID {
+				t.Errorf("GetByName() = %v, %v, want the new car %d", active, err, created.ID)
+			}
+
+			// The deleted car stays in the trash as it was
+			deleted, err := svc.GetCarByIDIncludingDeleted(ctx, original.ID)
+			if err != nil {
+				t.Fatalf("GetCarByIDIncludingDeleted() error = %v", err)
+			}
+			if deleted.DeletedAt == nil {
+				t.Error("deleted car was restored")
+			}
+			if deleted.ManufacturingValue != original.ManufacturingValue || deleted.Version != original.Version {
+				t.Errorf("deleted car = value %s version %d, want value %s version %d",
+					deleted.ManufacturingValue, deleted.Version, original.ManufacturingValue, original.Version)
+			}
+		})
+	}
+}
+
+func TestNormalizeBrand(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Honda", want: "Honda"},
+		{in: " honda ", want: "Honda"},
+		{in: "HONDA", want: "Honda"},
+		{in: " land  ROVER ", want: "Land Rover"},
+		{in: "alfa\tromeo", want: "Alfa Romeo"},
+		{in: "BMW", want: "BMW"},
+		{in: "bmw", want: "Bmw"},
+		{in: "škoda", want: "Škoda"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := normalizeBrand(tt.in); got != tt.want {
+				t.Errorf("normalizeBrand(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrandNormalization(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalize  bool
+		brands     []string
+		lookup     string
+		wantStored []string
+		wantListed []string
+		wantCount  int64
+	}{
+		{name: "enabled", normalize: true, brands: []string{" honda ", "HONDA"}, lookup: "Honda", wantStored: []string{"Honda", "Honda"}, wantListed: []string{"Honda"}, wantCount: 2},
+		{name: "disabled", normalize: false, brands: []string{" honda ", "HONDA"}, lookup: "Honda", wantStored: []string{"honda", "HONDA"}, wantListed: []string{"HONDA", "honda"}, wantCount: 2},
+		{name: "inner whitespace", normalize: true, brands: []string{"land  rover", "LAND ROVER"}, lookup: " land   rover ", wantStored: []string{"Land Rover", "Land Rover"}, wantListed: []string{"Land Rover"}, wantCount: 2},
+		{name: "inner whitespace when disabled", normalize: false, brands: []string{"land  rover", "LAND ROVER"}, lookup: "Land Rover", wantStored: []string{"land  rover", "LAND ROVER"}, wantListed: []string{"LAND ROVER", "land  rover"}, wantCount: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, _ := newTestService(t, func(cfg *config.Config) { cfg.NormalizeBrands = tt.normalize })
+			for i, brand := range tt.brands {
+				created := mustCreateCar(t, svc, carRequest(fmt.Sprintf("Car %d", i), brand))
+				if created.Brand != tt.wantStored[i] {
+					t.Errorf("brand %q stored as %q, want %q", brand, created.Brand, tt.wantStored[i])
+				}
+			}
+
+			brands, err := svc.ListBrands(ctx)
+			if err != nil {
+				t.Fatalf("ListBrands() error = %v", err)
+			}
+			if fmt.Sprint(brands) != fmt.Sprint(tt.wantListed) {
+				t.Errorf("ListBrands() = %q, want %q", brands, tt.wantListed)
+			}
+
+			// Lookups ignore case, but only match differing whitespace once normalized
+			count, err := svc.CountCarsByBrand(ctx, tt.lookup)
+			if err != nil {
+				t.Fatalf("CountCarsByBrand(%q) error = %v", tt.lookup, err)
+			}
+			if count.Count != tt.wantCount {
+				t.Errorf("CountCarsByBrand(%q) = %d, want %d", tt.lookup, count.Count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestPreviewPriceAdjustment(t *testing.T) {
+	svc, _ := newTestService(t, func(cfg *config.Config) {
+		cfg.MinManufacturingValue = 100
+		cfg.MaxManufacturingValue = 1000
+	})
+	ctx := context.Background()
+	for _, car := range []struct {
+		name  string
+		value float64
+	}{{"Civic", 200}, {"Accord", 900}, {"Fit", 150.50}} {
+		req := carRequest(car.name, "Honda")
+		req.ManufacturingValue = model.MoneyFromFloat(car.value)
+		mustCreateCar(t, svc, req)
+	}
+
+	tests := []struct {
+		name    string
+		percent float64
+		// want holds name:proposed price:below min:exceeds max per car
+		want []string
+	}{
+		{
+			name:    "increase",
+			percent: 15,
+			want:    []string{"Civic:230.00:false:false", "Accord:1035.00:false:true", "Fit:173.08:false:false"},
+		},
+		{
+			name:    "decrease",
+			percent: -35,
+			want:    []string{"Civic:130.00:false:false", "Accord:585.00:false:false", "Fit:97.83:true:false"},
+		},
+		{
+			name:    "at the bounds",
+			percent: -50,
+			want:    []string{"Civic:100.00:true:false", "Accord:450.00:false:false", "Fit:75.25:true:false"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview, err := svc.PreviewPriceAdjustment(ctx, &model.PriceAdjustmentRequest{Brand: "Honda", Percent: tt.percent}, 1, 10)
+			if err != nil {
+				t.Fatalf("PreviewPriceAdjustment(%g) error = %v", tt.percent, err)
+			}
+
+			got := make([]string, 0, len(preview.Cars))
+			for _, car := range preview.Cars {
+				got = append(got, fmt.Sprintf("%s:%s:%t:%t", car.Name, car.ProposedPrice, car.BelowMin, car.ExceedsMax))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("PreviewPriceAdjustment(%g) = %v, want %v", tt.percent, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("matches the applied prices", func(t *testing.T) {
+		const percent = 10
+		preview, err := svc.PreviewPriceAdjustment(ctx, &model.PriceAdjustmentRequest{Brand: "Honda", Percent: percent}, 1, 10)
+		if err != nil {
+			t.Fatalf("PreviewPriceAdjustment(%d) error = %v", percent, err)
+		}
+		if _, err := svc.UpdateBrandPrices(ctx, "Honda", percent); err != nil {
+			t.Fatalf("UpdateBrandPrices(%d) error = %v", percent, err)
+		}
+
+		for _, item := range preview.Cars {
+			car, err := svc.GetCarByID(ctx, item.ID)
+			if err != nil {
+				t.Fatalf("GetCarByID(%d) error = %v", item.ID, err)
+			}
+			if car.ManufacturingValue != item.ProposedPrice {
+				t.Errorf("%s applied price = %s, previewed %s", item.Name, car.ManufacturingValue, item.ProposedPrice)
+			}
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/username/go-car-service/internal/errs"
+	"github.com/username/go-car-service/internal/model"
+)
+
+// applyMergePatch applies a JSON merge patch (RFC 7386) to a car request and
+// returns the patched request. The patch must be a JSON object, and keys the
+// request does not have are rejected rather than silently dropped.
+func applyMergePatch(req *model.CarRequest, patch []byte) (*model.CarRequest, error) {
+	var patchDoc map[string]interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil || patchDoc == nil {
+		return nil, fmt.Errorf("%w: merge patch must be a JSON object", errs.ErrInvalidInput)
+	}
+
+	original, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode car: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode car: %w", err)
+	}
+
+	patched, err := json.Marshal(mergePatch(doc, patchDoc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patched car: %w", err)
+	}
+
+	var result model.CarRequest
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return nil, fmt.Errorf("%w: %s", errs.ErrUnknownField, strings.Trim(field, `"`))
+		}
+		return nil, fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	return &result, nil
+}
+
+// mergePatch merges patch into target following RFC 7386: null members are
+// removed, object members are merged recursively and any other value replaces
+// the target's
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{}, len(patchObj))
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
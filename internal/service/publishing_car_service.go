@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/username/go-car-service/internal/events"
+	"github.com/username/go-car-service/internal/model"
+)
+
+// publishingCarService decorates a CarService, publishing an event for every car
+// created or deleted once the change has been committed
+type publishingCarService struct {
+	CarService
+	publisher events.EventPublisher
+}
+
+// NewPublishingCarService wraps next so that car creations and deletions are published
+func NewPublishingCarService(next CarService, publisher events.EventPublisher) CarService {
+	return &publishingCarService{CarService: next, publisher: publisher}
+}
+
+// CreateCar creates a car and publishes its creation. A replayed request created
+// nothing, so it is not published again.
+func (s *publishingCarService) CreateCar(ctx context.Context, req *model.CarRequest, idempotencyKey IdempotencyKey) (*model.CarResponse, bool, error) {
+	car, replayed, err := s.CarService.CreateCar(ctx, req, idempotencyKey)
+	if err == nil && !replayed {
+		s.publish(ctx, events.CarCreated, car.ID, car)
+	}
+	return car, replayed, err
+}
+
+// CreateCars creates several cars and publishes each creation
+func (s *publishingCarService) CreateCars(ctx context.Context, reqs []*model.CarRequest) ([]*model.CarResponse, error) {
+	cars, err := s.CarService.CreateCars(ctx, reqs)
+	if err == nil {
+		for _, car := range cars {
+			s.publish(ctx, events.CarCreated, car.ID, car)
+		}
+	}
+	return cars, err
+}
+
+// UpsertCarByName upserts a car and publishes its creation when it is new
+func (s *publishingCarService) UpsertCarByName(ctx context.Context, name string, req *model.CarRequest) (*model.CarResponse, bool, error) {
+	car, created, err := s.CarService.UpsertCarByName(ctx, name, req)
+	if err == nil && created {
+		s.publish(ctx, events.CarCreated, car.ID, car)
+	}
+	return car, created, err
+}
+
+// DeleteCar deletes a car and publishes its deletion
+func (s *publishingCarService) DeleteCar(ctx context.Context, id int64) error {
+	err := s.CarService.DeleteCar(ctx, id)
+	if err == nil {
+		s.publish(ctx, events.CarDeleted, id, nil)
+	}
+	return err
+}
+
+// DeleteCars deletes several cars and publishes each deletion
+func (s *publishingCarService) DeleteCars(ctx context.Context, ids []int64) (*model.DeleteCarsResponse, error) {
+	result, err := s.CarService.DeleteCars(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[int64]bool, len(result.NotFound))
+	for _, id := range result.NotFound {
+		skip[id] = true
+	}
+	for _, id := range ids {
+		if !skip[id] {
+			s.publish(ctx, events.CarDeleted, id, nil)
+			skip[id] = true
+		}
+	}
+
+	return result, nil
+}
+
+// MergeCars merges two cars and publishes the deletion of the duplicate
+func (s *publishingCarService) MergeCars(ctx context.Context, req *model.MergeCarsRequest) (*model.CarResponse, error) {
+	car, err := s.CarService.MergeCars(ctx, req)
+	if err == nil {
+		s.publish(ctx, events.CarDeleted, req.DuplicateID, nil)
+	}
+	return car, err
+}
+
+// ProcessScheduledDeletions deletes the due cars and publishes each deletion
+func (s *publishingCarService) ProcessScheduledDeletions(ctx context.Context) ([]int64, error) {
+	deleted, err := s.CarService.ProcessScheduledDeletions(ctx)
+	for _, id := range deleted {
+		s.publish(ctx, events.CarDeleted, id, nil)
+	}
+	return deleted, err
+}
+
+// PurgeDeletedCars purges the expired deleted cars and publishes each purge
+func (s *publishingCarService) PurgeDeletedCars(ctx context.Context) ([]int64, error) {
+	purged, err := s.CarService.PurgeDeletedCars(ctx)
+	for _, id := range purged {
+		s.publish(ctx, events.CarPurged, id, nil)
+	}
+	return purged, err
+}
+
+// publish hands an event to the publisher, which delivers it in the background
+func (s *publishingCarService) publish(ctx context.Context, eventType string, carID int64, car *model.CarResponse) {
+	s.publisher.Publish(ctx, events.Event{
+		Type:       eventType,
+		CarID:      carID,
+		Car:        car,
+		OccurredAt: time.Now().UTC(),
+	})
+}
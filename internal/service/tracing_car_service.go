@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/username/go-car-service/internal/model"
+	"github.com/username/go-car-service/pkg/tracing"
+)
+
+// tracingCarService decorates a CarService, wrapping every call in a span named
+// after the method. The span's context is passed on, so repository spans nest under it.
+type tracingCarService struct {
+	CarService
+}
+
+// NewTracingCarService wraps next so that its calls are traced
+func NewTracingCarService(next CarService) CarService {
+	return &tracingCarService{CarService: next}
+}
+
+// CreateCar traces CarService.CreateCar
+func (s *tracingCarService) CreateCar(ctx context.Context, req *model.CarRequest, idempotencyKey IdempotencyKey) (*model.CarResponse, bool, error) {
+	ctx, span := tracing.Start(ctx, "CarService.CreateCar")
+	car, replayed, err := s.CarService.CreateCar(ctx, req, idempotencyKey)
+	tracing.End(span, err)
+	return car, replayed, err
+}
+
+// CreateCars traces CarService.CreateCars
+func (s *tracingCarService) CreateCars(ctx context.Context, reqs []*model.CarRequest) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.CreateCars")
+	cars, err := s.CarService.CreateCars(ctx, reqs)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// ValidateCars traces CarService.ValidateCars
+func (s *tracingCarService) ValidateCars(ctx context.Context, reqs []*model.CarRequest) (*model.BatchValidationResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.ValidateCars")
+	result, err := s.CarService.ValidateCars(ctx, reqs)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetCarByID traces CarService.GetCarByID
+func (s *tracingCarService) GetCarByID(ctx context.Context, id int64) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarByID", tracing.CarIDKey.Int64(id))
+	car, err := s.CarService.GetCarByID(ctx, id)
+	tracing.End(span, err)
+	return car, err
+}
+
+// GetCarByIDIncludingDeleted traces CarService.GetCarByIDIncludingDeleted
+func (s *tracingCarService) GetCarByIDIncludingDeleted(ctx context.Context, id int64) (*model.DeletedCarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarByIDIncludingDeleted", tracing.CarIDKey.Int64(id))
+	car, err := s.CarService.GetCarByIDIncludingDeleted(ctx, id)
+	tracing.End(span, err)
+	return car, err
+}
+
+// GetCarsByIDs traces CarService.GetCarsByIDs
+func (s *tracingCarService) GetCarsByIDs(ctx context.Context, ids []int64) (*model.CarBatchResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsByIDs")
+	result, err := s.CarService.GetCarsByIDs(ctx, ids)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetCarByName traces CarService.GetCarByName
+func (s *tracingCarService) GetCarByName(ctx context.Context, name, brand string) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarByName")
+	car, err := s.CarService.GetCarByName(ctx, name, brand)
+	tracing.End(span, err)
+	return car, err
+}
+
+// CheckNameAvailable traces CarService.CheckNameAvailable
+func (s *tracingCarService) CheckNameAvailable(ctx context.Context, name, brand string) (*model.NameAvailabilityResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.CheckNameAvailable")
+	availability, err := s.CarService.CheckNameAvailable(ctx, name, brand)
+	tracing.End(span, err)
+	return availability, err
+}
+
+// GetCarsByBrand traces CarService.GetCarsByBrand
+func (s *tracingCarService) GetCarsByBrand(ctx context.Context, brand string) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsByBrand")
+	cars, err := s.CarService.GetCarsByBrand(ctx, brand)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetCarsByColor traces CarService.GetCarsByColor
+func (s *tracingCarService) GetCarsByColor(ctx context.Context, color string) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsByColor")
+	cars, err := s.CarService.GetCarsByColor(ctx, color)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetCarsCreatedOn traces CarService.GetCarsCreatedOn
+func (s *tracingCarService) GetCarsCreatedOn(ctx context.Context, date time.Time) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsCreatedOn")
+	cars, err := s.CarService.GetCarsCreatedOn(ctx, date)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// CountCars traces CarService.CountCars
+func (s *tracingCarService) CountCars(ctx context.Context) (*model.CarCountResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.CountCars")
+	result, err := s.CarService.CountCars(ctx)
+	tracing.End(span, err)
+	return result, err
+}
+
+// CountCarsByBrand traces CarService.CountCarsByBrand
+func (s *tracingCarService) CountCarsByBrand(ctx context.Context, brand string) (*model.BrandCountResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.CountCarsByBrand")
+	result, err := s.CarService.CountCarsByBrand(ctx, brand)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetCarsByPriceRange traces CarService.GetCarsByPriceRange
+func (s *tracingCarService) GetCarsByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsByPriceRange")
+	cars, err := s.CarService.GetCarsByPriceRange(ctx, minPrice, maxPrice)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetCarsByYearRange traces CarService.GetCarsByYearRange
+func (s *tracingCarService) GetCarsByYearRange(ctx context.Context, startYear, finalYear int) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsByYearRange")
+	cars, err := s.CarService.GetCarsByYearRange(ctx, startYear, finalYear)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetAllCars traces CarService.GetAllCars
+func (s *tracingCarService) GetAllCars(ctx context.Context, page, pageSize int) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetAllCars")
+	cars, err := s.CarService.GetAllCars(ctx, page, pageSize)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetCarsPage traces CarService.GetCarsPage
+func (s *tracingCarService) GetCarsPage(ctx context.Context, page, pageSize int) (*model.CarListPage, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsPage")
+	result, err := s.CarService.GetCarsPage(ctx, page, pageSize)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetCarsAfter traces CarService.GetCarsAfter
+func (s *tracingCarService) GetCarsAfter(ctx context.Context, afterID int64, pageSize int) (*model.CarPage, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarsAfter")
+	result, err := s.CarService.GetCarsAfter(ctx, afterID, pageSize)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetCarChanges traces CarService.GetCarChanges
+func (s *tracingCarService) GetCarChanges(ctx context.Context, after model.ChangeCursor, pageSize int) (*model.CarChangesResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarChanges")
+	changes, err := s.CarService.GetCarChanges(ctx, after, pageSize)
+	tracing.End(span, err)
+	return changes, err
+}
+
+// GetRelatedCars traces CarService.GetRelatedCars
+func (s *tracingCarService) GetRelatedCars(ctx context.Context, id int64, limit int) (*model.RelatedCarsResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetRelatedCars", tracing.CarIDKey.Int64(id))
+	related, err := s.CarService.GetRelatedCars(ctx, id, limit)
+	tracing.End(span, err)
+	return related, err
+}
+
+// GetRecentCars traces CarService.GetRecentCars
+func (s *tracingCarService) GetRecentCars(ctx context.Context, limit int) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetRecentCars")
+	cars, err := s.CarService.GetRecentCars(ctx, limit)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// GetCarExtremes traces CarService.GetCarExtremes
+func (s *tracingCarService) GetCarExtremes(ctx context.Context) (*model.CarExtremesResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarExtremes")
+	result, err := s.CarService.GetCarExtremes(ctx)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetDeletedCars traces CarService.GetDeletedCars
+func (s *tracingCarService) GetDeletedCars(ctx context.Context, page, pageSize int) ([]*model.DeletedCarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetDeletedCars")
+	result, err := s.CarService.GetDeletedCars(ctx, page, pageSize)
+	tracing.End(span, err)
+	return result, err
+}
+
+// FilterCars traces CarService.FilterCars
+func (s *tracingCarService) FilterCars(ctx context.Context, filter model.CarFilter) ([]*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.FilterCars")
+	cars, err := s.CarService.FilterCars(ctx, filter)
+	tracing.End(span, err)
+	return cars, err
+}
+
+// StreamCars traces CarService.StreamCars
+func (s *tracingCarService) StreamCars(ctx context.Context, fn func(car *model.CarResponse) error) error {
+	ctx, span := tracing.Start(ctx, "CarService.StreamCars")
+	err := s.CarService.StreamCars(ctx, fn)
+	tracing.End(span, err)
+	return err
+}
+
+// StreamCarsByBrand traces CarService.StreamCarsByBrand
+func (s *tracingCarService) StreamCarsByBrand(ctx context.Context, brand string, fn func(car *model.CarResponse) error) error {
+	ctx, span := tracing.Start(ctx, "CarService.StreamCarsByBrand")
+	err := s.CarService.StreamCarsByBrand(ctx, brand, fn)
+	tracing.End(span, err)
+	return err
+}
+
+// GetRandomFeaturedCar traces CarService.GetRandomFeaturedCar
+func (s *tracingCarService) GetRandomFeaturedCar(ctx context.Context) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetRandomFeaturedCar")
+	car, err := s.CarService.GetRandomFeaturedCar(ctx)
+	tracing.End(span, err)
+	return car, err
+}
+
+// SearchCars traces CarService.SearchCars
+func (s *tracingCarService) SearchCars(ctx context.Context, query string, page, pageSize int) ([]*model.CarResponse, bool, error) {
+	ctx, span := tracing.Start(ctx, "CarService.SearchCars")
+	cars, capped, err := s.CarService.SearchCars(ctx, query, page, pageSize)
+	tracing.End(span, err)
+	return cars, capped, err
+}
+
+// GetCatalogSummary traces CarService.GetCatalogSummary
+func (s *tracingCarService) GetCatalogSummary(ctx context.Context) (*model.CatalogSummaryResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCatalogSummary")
+	result, err := s.CarService.GetCatalogSummary(ctx)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetBrandsByValue traces CarService.GetBrandsByValue
+func (s *tracingCarService) GetBrandsByValue(ctx context.Context, limit int) ([]*model.BrandValueResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetBrandsByValue")
+	result, err := s.CarService.GetBrandsByValue(ctx, limit)
+	tracing.End(span, err)
+	return result, err
+}
+
+// ListBrands traces CarService.ListBrands
+func (s *tracingCarService) ListBrands(ctx context.Context) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "CarService.ListBrands")
+	brands, err := s.CarService.ListBrands(ctx)
+	tracing.End(span, err)
+	return brands, err
+}
+
+// GetStatsByBrand traces CarService.GetStatsByBrand
+func (s *tracingCarService) GetStatsByBrand(ctx context.Context, page, pageSize int) ([]*model.BrandStatsResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetStatsByBrand")
+	result, err := s.CarService.GetStatsByBrand(ctx, page, pageSize)
+	tracing.End(span, err)
+	return result, err
+}
+
+// UpdateCar traces CarService.UpdateCar
+func (s *tracingCarService) UpdateCar(ctx context.Context, id int64, req *model.CarRequest) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.UpdateCar", tracing.CarIDKey.Int64(id))
+	car, err := s.CarService.UpdateCar(ctx, id, req)
+	tracing.End(span, err)
+	return car, err
+}
+
+// PatchCar traces CarService.PatchCar
+func (s *tracingCarService) PatchCar(ctx context.Context, id int64, patch []byte, version int) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.PatchCar", tracing.CarIDKey.Int64(id))
+	car, err := s.CarService.PatchCar(ctx, id, patch, version)
+	tracing.End(span, err)
+	return car, err
+}
+
+// UpdatePrice traces CarService.UpdatePrice
+func (s *tracingCarService) UpdatePrice(ctx context.Context, id int64, value model.Money) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.UpdatePrice", tracing.CarIDKey.Int64(id))
+	car, err := s.CarService.UpdatePrice(ctx, id, value)
+	tracing.End(span, err)
+	return car, err
+}
+
+// UpdateBrandPrices traces CarService.UpdateBrandPrices
+func (s *tracingCarService) UpdateBrandPrices(ctx context.Context, brand string, percentChange float64) (*model.BrandPriceUpdateResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.UpdateBrandPrices")
+	result, err := s.CarService.UpdateBrandPrices(ctx, brand, percentChange)
+	tracing.End(span, err)
+	return result, err
+}
+
+// UpsertCarByName traces CarService.UpsertCarByName
+func (s *tracingCarService) UpsertCarByName(ctx context.Context, name string, req *model.CarRequest) (*model.CarResponse, bool, error) {
+	ctx, span := tracing.Start(ctx, "CarService.UpsertCarByName")
+	car, created, err := s.CarService.UpsertCarByName(ctx, name, req)
+	tracing.End(span, err)
+	return car, created, err
+}
+
+// DeleteCar traces CarService.DeleteCar
+func (s *tracingCarService) DeleteCar(ctx context.Context, id int64) error {
+	ctx, span := tracing.Start(ctx, "CarService.DeleteCar", tracing.CarIDKey.Int64(id))
+	err := s.CarService.DeleteCar(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+// DeleteCars traces CarService.DeleteCars
+func (s *tracingCarService) DeleteCars(ctx context.Context, ids []int64) (*model.DeleteCarsResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.DeleteCars")
+	result, err := s.CarService.DeleteCars(ctx, ids)
+	tracing.End(span, err)
+	return result, err
+}
+
+// MergeCars traces CarService.MergeCars
+func (s *tracingCarService) MergeCars(ctx context.Context, req *model.MergeCarsRequest) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.MergeCars")
+	car, err := s.CarService.MergeCars(ctx, req)
+	tracing.End(span, err)
+	return car, err
+}
+
+// ScheduleCarDeletion traces CarService.ScheduleCarDeletion
+func (s *tracingCarService) ScheduleCarDeletion(ctx context.Context, id int64, at time.Time) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.ScheduleCarDeletion", tracing.CarIDKey.Int64(id))
+	car, err := s.CarService.ScheduleCarDeletion(ctx, id, at)
+	tracing.End(span, err)
+	return car, err
+}
+
+// CancelCarDeletion traces CarService.CancelCarDeletion
+func (s *tracingCarService) CancelCarDeletion(ctx context.Context, id int64) (*model.CarResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.CancelCarDeletion", tracing.CarIDKey.Int64(id))
+	car, err := s.CarService.CancelCarDeletion(ctx, id)
+	tracing.End(span, err)
+	return car, err
+}
+
+// ProcessScheduledDeletions traces CarService.ProcessScheduledDeletions
+func (s *tracingCarService) ProcessScheduledDeletions(ctx context.Context) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "CarService.ProcessScheduledDeletions")
+	deleted, err := s.CarService.ProcessScheduledDeletions(ctx)
+	tracing.End(span, err)
+	return deleted, err
+}
+
+// PurgeDeletedCars traces CarService.PurgeDeletedCars
+func (s *tracingCarService) PurgeDeletedCars(ctx context.Context) ([]int64, error) {
+	ctx, span := tracing.Start(ctx, "CarService.PurgeDeletedCars")
+	purged, err := s.CarService.PurgeDeletedCars(ctx)
+	tracing.End(span, err)
+	return purged, err
+}
+
+// PurgeIdempotencyKeys traces CarService.PurgeIdempotencyKeys
+func (s *tracingCarService) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CarService.PurgeIdempotencyKeys")
+	purged, err := s.CarService.PurgeIdempotencyKeys(ctx)
+	tracing.End(span, err)
+	return purged, err
+}
+
+// ValidateField traces CarService.ValidateField
+func (s *tracingCarService) ValidateField(ctx context.Context, req *model.FieldValidationRequest) (*model.FieldValidationResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.ValidateField")
+	result, err := s.CarService.ValidateField(ctx, req)
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetCarSchema traces CarService.GetCarSchema
+func (s *tracingCarService) GetCarSchema(ctx context.Context) (*model.CarSchemaResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.GetCarSchema")
+	schema, err := s.CarService.GetCarSchema(ctx)
+	tracing.End(span, err)
+	return schema, err
+}
+
+// PreviewPriceAdjustment traces CarService.PreviewPriceAdjustment
+func (s *tracingCarService) PreviewPriceAdjustment(ctx context.Context, req *model.PriceAdjustmentRequest, page, pageSize int) (*model.PriceAdjustmentPreviewResponse, error) {
+	ctx, span := tracing.Start(ctx, "CarService.PreviewPriceAdjustment")
+	result, err := s.CarService.PreviewPriceAdjustment(ctx, req, page, pageSize)
+	tracing.End(span, err)
+	return result, err
+}
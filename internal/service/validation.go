@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/username/go-car-service/internal/errs"
+	"github.com/username/go-car-service/internal/model"
+)
+
+// Bounds for a car model year
+const (
+	MinYear = 1900
+	MaxYear = 2100
+)
+
+// Maximum lengths in characters, matching the sizes of the cars.name, cars.brand
+// and cars.color columns
+const (
+	MaxNameLength  = 100
+	MaxBrandLength = 100
+	MaxColorLength = 50
+)
+
+// validateCarRequest validates the car request, trimming the surrounding
+// whitespace of its name and brand first. Failures wrap errs.ErrInvalidInput.
+func (s *carService) validateCarRequest(req *model.CarRequest) error {
+	if req == nil {
+		return fmt.Errorf("%w: request cannot be nil", errs.ErrInvalidInput)
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Brand = strings.TrimSpace(req.Brand)
+
+	if err := validateName(req.Name); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	if err := validateBrand(req.Brand); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	if err := s.validateManufacturingValue(req.ManufacturingValue); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	if err := validateYear(req.Year); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	if err := validateWeight(req.Weight); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	if err := validateColor(req.Color); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	if err := s.validateDescription(req.Description); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidInput, err)
+	}
+
+	return nil
+}
+
+// validateName validates a trimmed car name
+func validateName(name string) error {
+	if name == "" {
+		return errors.New("car name is required")
+	}
+
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return fmt.Errorf("car name cannot be longer than %d characters", MaxNameLength)
+	}
+
+	return nil
+}
+
+// validateBrand validates a trimmed car brand
+func validateBrand(brand string) error {
+	if brand == "" {
+		return errors.New("car brand is required")
+	}
+
+	if utf8.RuneCountInString(brand) > MaxBrandLength {
+		return fmt.Errorf("car brand cannot be longer than %d characters", MaxBrandLength)
+	}
+
+	return nil
+}
+
+// validateManufacturingValue validates a car manufacturing value against the
+// configured exclusive bounds
+func (s *carService) validateManufacturingValue(money model.Money) error {
+	value := money.Float64()
+	if value <= s.cfg.MinManufacturingValue {
+		return fmt.Errorf("manufacturing value must be greater than %g", s.cfg.MinManufacturingValue)
+	}
+
+	if value >= s.cfg.MaxManufacturingValue {
+		return fmt.Errorf("manufacturing value must be less than %g", s.cfg.MaxManufacturingValue)
+	}
+
+	return nil
+}
+
+// validateYear validates a car model year; 0 means the year is unknown
+func validateYear(year int) error {
+	if year == 0 {
+		return nil
+	}
+
+	if year < MinYear || year > MaxYear {
+		return fmt.Errorf("year must be between %d and %d", MinYear, MaxYear)
+	}
+
+	return nil
+}
+
+// validateWeight validates a featured selection weight; 0 means the default weight
+func validateWeight(weight int) error {
+	if weight < 0 {
+		return errors.New("weight must be positive")
+	}
+
+	return nil
+}
+
+// validateColor validates an optional car color
+func validateColor(color *string) error {
+	if color != nil && utf8.RuneCountInString(*color) > MaxColorLength {
+		return fmt.Errorf("color cannot be longer than %d characters", MaxColorLength)
+	}
+
+	return nil
+}
+
+// validateDescription validates an optional car description against the
+// configured maximum length; a non-positive maximum disables the check
+func (s *carService) validateDescription(description *string) error {
+	if description != nil && s.cfg.MaxDescriptionLength > 0 && utf8.RuneCountInString(*description) > s.cfg.MaxDescriptionLength {
+		return fmt.Errorf("description cannot be longer than %d characters", s.cfg.MaxDescriptionLength)
+	}
+
+	return nil
+}
+
+// GetCarSchema describes the fields of a car payload with the rules
+// validateCarRequest applies to them. It draws on the same constants and
+// configuration as the validators, so the two can't drift apart.
+func (s *carService) GetCarSchema(ctx context.Context) (*model.CarSchemaResponse, error) {
+	intPtr := func(v int) *int { return &v }
+	floatPtr := func(v float64) *float64 { return &v }
+
+	description := model.FieldSchema{Name: "description", Type: "string"}
+	if s.cfg.MaxDescriptionLength > 0 {
+		description.MaxLength = intPtr(s.cfg.MaxDescriptionLength)
+	}
+
+	return &model.CarSchemaResponse{
+		Fields: []model.FieldSchema{
+			{Name: "name", Type: "string", Required: true, MaxLength: intPtr(MaxNameLength), Description: "Surrounding whitespace is trimmed"},
+			{Name: "brand", Type: "string", Required: true, MaxLength: intPtr(MaxBrandLength), Description: "Surrounding whitespace is trimmed"},
+			{
+				Name:             "manufacturing_value",
+				Type:             "number",
+				Required:         true,
+				ExclusiveMinimum: floatPtr(s.cfg.MinManufacturingValue),
+				ExclusiveMaximum: floatPtr(s.cfg.MaxManufacturingValue),
+				Description:      "Amount with at most two decimals",
+			},
+			description,
+			{Name: "color", Type: "string", MaxLength: intPtr(MaxColorLength)},
+			{Name: "year", Type: "integer", Minimum: floatPtr(MinYear), Maximum: floatPtr(MaxYear), Description: "0 or absent when unknown"},
+			{Name: "featured", Type: "boolean"},
+			{Name: "weight", Type: "integer", Minimum: floatPtr(0), Description: fmt.Sprintf("Featured selection weight; 0 or absent means %d", model.DefaultWeight)},
+			{Name: "version", Type: "integer", Minimum: floatPtr(1), Description: "Version the update is based on; required on update, ignored on create"},
+		},
+	}, nil
+}
+
+// validateCarFilter checks that the price, year and time bounds of a filter are sane
+func validateCarFilter(filter model.CarFilter) error {
+	if (filter.MinPrice != nil && !isFinite(*filter.MinPrice)) || (filter.MaxPrice != nil && !isFinite(*filter.MaxPrice)) {
+		return fmt.Errorf("%w: price bounds must be finite numbers", errs.ErrInvalidInput)
+	}
+
+	if (filter.MinPrice != nil && *filter.MinPrice < 0) || (filter.MaxPrice != nil && *filter.MaxPrice < 0) {
+		return fmt.Errorf("%w: price bounds must not be negative", errs.ErrInvalidInput)
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return fmt.Errorf("%w: minPrice must not exceed maxPrice", errs.ErrInvalidInput)
+	}
+
+	if (filter.MinYear != nil && (*filter.MinYear < MinYear || *filter.MinYear > MaxYear)) ||
+		(filter.MaxYear != nil && (*filter.MaxYear < MinYear || *filter.MaxYear > MaxYear)) {
+		return fmt.Errorf("%w: year bounds must be between %d and %d", errs.ErrInvalidInput, MinYear, MaxYear)
+	}
+
+	if filter.MinYear != nil && filter.MaxYear != nil && *filter.MinYear > *filter.MaxYear {
+		return fmt.Errorf("%w: minYear must not exceed maxYear", errs.ErrInvalidInput)
+	}
+
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && filter.CreatedAfter.After(*filter.CreatedBefore) {
+		return fmt.Errorf("%w: createdAfter must not be later than createdBefore", errs.ErrInvalidInput)
+	}
+
+	if filter.UpdatedAfter != nil && filter.UpdatedBefore != nil && filter.UpdatedAfter.After(*filter.UpdatedBefore) {
+		return fmt.Errorf("%w: updatedAfter must not be later than updatedBefore", errs.ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// validatePriceAdjustment validates a price adjustment request
+func validatePriceAdjustment(req *model.PriceAdjustmentRequest) error {
+	if req == nil || strings.TrimSpace(req.Brand) == "" {
+		return fmt.Errorf("%w: brand is required", errs.ErrInvalidInput)
+	}
+
+	if req.Percent <= -100 {
+		return fmt.Errorf("%w: percent must be greater than -100", errs.ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// ValidateField checks a single request field against the same rules used when
+// creating a car, including name uniqueness
+func (s *carService) ValidateField(ctx context.Context, req *model.FieldValidationRequest) (*model.FieldValidationResponse, error) {
+	var err error
+
+	switch req.Field {
+	case "name":
+		name, ok := req.Value.(string)
+		if !ok {
+			err = errors.New("car name must be a string")
+			break
+		}
+		name = strings.TrimSpace(name)
+
+		if err = validateName(name); err != nil {
+			break
+		}
+
+		// With names unique per brand, whether the name is taken depends on a brand we don't have
+		if s.cfg.UniqueByNameBrand {
+			break
+		}
+
+		existingCar, lookupErr := s.repo.GetByName(ctx, name, false)
+		if lookupErr != nil && !errors.Is(lookupErr, errs.ErrCarNotFound) {
+			logError(ctx, lookupErr, "Failed to get car by name %s: %v", name, lookupErr)
+			return nil, fmt.Errorf("failed to check car name: %w", lookupErr)
+		}
+		if lookupErr == nil && existingCar != nil {
+			err = fmt.Errorf("car with name %s already exists", name)
+		}
+	case "brand":
+		brand, ok := req.Value.(string)
+		if !ok {
+			err = errors.New("car brand must be a string")
+			break
+		}
+
+		err = validateBrand(strings.TrimSpace(brand))
+	case "manufacturing_value":
+		value, ok := req.Value.(float64)
+		if !ok {
+			err = errors.New("manufacturing value must be a number")
+			break
+		}
+
+		// The shortest representation of the decoded number holds the decimals as sent
+		money, parseErr := model.ParseMoney(strconv.FormatFloat(value, 'f', -1, 64))
+		if parseErr != nil {
+			err = parseErr
+			break
+		}
+
+		err = s.validateManufacturingValue(money)
+	case "year":
+		year, ok := req.Value.(float64)
+		if !ok || year != float64(int(year)) {
+			err = errors.New("year must be a whole number")
+			break
+		}
+
+		err = validateYear(int(year))
+	case "weight":
+		weight, ok := req.Value.(float64)
+		if !ok || weight != float64(int(weight)) {
+			err = errors.New("weight must be a whole number")
+			break
+		}
+
+		err = validateWeight(int(weight))
+	case "description":
+		description, ok := req.Value.(string)
+		if !ok && req.Value != nil {
+			err = errors.New("description must be a string")
+			break
+		}
+
+		if ok {
+			err = s.validateDescription(&description)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errs.ErrUnknownField, req.Field)
+	}
+
+	resp := &model.FieldValidationResponse{
+		Field: req.Field,
+		Valid: err == nil,
+	}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+
+	return resp, nil
+}
+
+// isFinite reports whether value is neither NaN nor an infinity
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/username/go-car-service/internal/config"
+	"github.com/username/go-car-service/internal/errs"
+	"github.com/username/go-car-service/internal/model"
+)
+
+func TestWeightValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		weight     int
+		wantErr    error
+		wantWeight int
+	}{
+		{name: "negative", weight: -1, wantErr: errs.ErrInvalidInput},
+		{name: "unset uses the default", weight: 0, wantWeight: model.DefaultWeight},
+		{name: "positive", weight: 5, wantWeight: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, _ := newTestService(t, nil)
+			existing := mustCreateCar(t, svc, carRequest("Accord", "Honda"))
+
+			req := carRequest("Civic", "Honda")
+			req.Featured = true
+			req.Weight = tt.weight
+			created, _, createErr := svc.CreateCar(ctx, req, IdempotencyKey{})
+
+			update := carRequest("Accord", "Honda")
+			update.Weight = tt.weight
+			update.Version = existing.Version
+			updated, updateErr := svc.UpdateCar(ctx, existing.ID, update)
+
+			if tt.wantErr != nil {
+				if !errors.Is(createErr, tt.wantErr) || !errors.Is(updateErr, tt.wantErr) {
+					t.Fatalf("CreateCar() error = %v, UpdateCar() error = %v, want %v", createErr, updateErr, tt.wantErr)
+				}
+				return
+			}
+			if createErr != nil || updateErr != nil {
+				t.Fatalf("CreateCar() error = %v, UpdateCar() error = %v", createErr, updateErr)
+			}
+			if created.Weight != tt.wantWeight || updated.Weight != tt.wantWeight {
+				t.Errorf("weights = %d and %d, want %d", created.Weight, updated.Weight, tt.wantWeight)
+			}
+		})
+	}
+}
+
+func TestGetRandomFeaturedCar(t *testing.T) {
+	tests := []struct {
+		name     string
+		featured bool
+		wantErr  error
+	}{
+		{name: "no featured cars", featured: false, wantErr: errs.ErrCarNotFound},
+		{name: "featured car", featured: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t, nil)
+			req := carRequest("Civic", "Honda")
+			req.Featured = tt.featured
+			created := mustCreateCar(t, svc, req)
+
+			car, err := svc.GetRandomFeaturedCar(context.Background())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetRandomFeaturedCar() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRandomFeaturedCar() error = %v", err)
+			}
+			if car.ID != created.ID {
+				t.Errorf("GetRandomFeaturedCar() = car %d, want %d", car.ID, created.ID)
+			}
+		})
+	}
+}
+
+func TestNameAndBrandValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		carName   string
+		brand     string
+		wantErr   error
+		wantName  string
+		wantBrand string
+	}{
+		{name: "trimmed", carName: "  Civic ", brand: "\tHonda ", wantName: "Civic", wantBrand: "Honda"},
+		{name: "whitespace-only name", carName: "   ", brand: "Honda", wantErr: errs.ErrInvalidInput},
+		{name: "whitespace-only brand", carName: "Civic", brand: " \t ", wantErr: errs.ErrInvalidInput},
+		{name: "name at the limit", carName: strings.Repeat("n", MaxNameLength), brand: "Honda", wantName: strings.Repeat("n", MaxNameLength), wantBrand: "Honda"},
+		{name: "name over the limit", carName: strings.Repeat("n", MaxNameLength+1), brand: "Honda", wantErr: errs.ErrInvalidInput},
+		{name: "multibyte name at the limit", carName: strings.Repeat("é", MaxNameLength), brand: "Honda", wantName: strings.Repeat("é", MaxNameLength), wantBrand: "Honda"},
+		{name: "brand at the limit", carName: "Civic", brand: strings.Repeat("b", MaxBrandLength), wantName: "Civic", wantBrand: strings.Repeat("b", MaxBrandLength)},
+		{name: "brand over the limit", carName: "Civic", brand: strings.Repeat("b", MaxBrandLength+1), wantErr: errs.ErrInvalidInput},
+		{name: "padding doesn't count towards the limit", carName: " " + strings.Repeat("n", MaxNameLength) + " ", brand: "Honda", wantName: strings.Repeat("n", MaxNameLength), wantBrand: "Honda"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t, nil)
+
+			created, _, err := svc.CreateCar(context.Background(), carRequest(tt.carName, tt.brand), IdempotencyKey{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateCar() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateCar() error = %v", err)
+			}
+			if created.Name != tt.wantName || created.Brand != tt.wantBrand {
+				t.Errorf("stored %q (%q), want %q (%q)", created.Name, created.Brand, tt.wantName, tt.wantBrand)
+			}
+		})
+	}
+}
+
+func TestDescriptionLengthValidation(t *testing.T) {
+	const limit = 20
+
+	tests := []struct {
+		name        string
+		maxLength   int
+		description string
+		wantErr     error
+	}{
+		{name: "at the limit", maxLength: limit, description: strings.Repeat("d", limit)},
+		{name: "one over the limit", maxLength: limit, description: strings.Repeat("d", limit+1), wantErr: errs.ErrInvalidInput},
+		{name: "multibyte at the limit", maxLength: limit, description: strings.Repeat("ü", limit)},
+		{name: "limit disabled", maxLength: 0, description: strings.Repeat("d", 10*limit)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, _ := newTestService(t, func(cfg *config.Config) { cfg.MaxDescriptionLength = tt.maxLength })
+			existing := mustCreateCar(t, svc, carRequest("Accord", "Honda"))
+
+			req := carRequest("Civic", "Honda")
+			req.Description = &tt.description
+			_, _, createErr := svc.CreateCar(ctx, req, IdempotencyKey{})
+
+			update := carRequest("Accord", "Honda")
+			update.Description = &tt.description
+			update.Version = existing.Version
+			_, updateErr := svc.UpdateCar(ctx, existing.ID, update)
+
+			patch, _ := json.Marshal(map[string]string{"description": tt.description})
+			_, patchErr := svc.PatchCar(ctx, existing.ID, patch, 0)
+
+			for op, err := range map[string]error{"CreateCar": createErr, "UpdateCar": updateErr, "PatchCar": patchErr} {
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s() error = %v, want %v", op, err, tt.wantErr)
+				}
+				if tt.wantErr == nil && err != nil {
+					t.Errorf("%s() error = %v", op, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCarSchemaMatchesValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *config.Config)
+	}{
+		{name: "defaults"},
+		{name: "custom bounds", configure: func(cfg *config.Config) {
+			cfg.MaxDescriptionLength = 30
+			cfg.MinManufacturingValue = 1000
+			cfg.MaxManufacturingValue = 50000
+		}},
+		{name: "description limit disabled", configure: func(cfg *config.Config) { cfg.MaxDescriptionLength = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, _ := newTestService(t, tt.configure)
+			schema, err := svc.GetCarSchema(ctx)
+			if err != nil {
+				t.Fatalf("GetCarSchema() error = %v", err)
+			}
+
+			// Every bound the schema advertises is the exact point where validation flips
+			for _, field := range schema.Fields {
+				var valid, invalid []interface{}
+				if field.MaxLength != nil {
+					valid = append(valid, strings.Repeat("x", *field.MaxLength))
+					invalid = append(invalid, strings.Repeat("x", *field.MaxLength+1))
+				}
+				if field.Minimum != nil {
+					valid = append(valid, *field.Minimum)
+					invalid = append(invalid, *field.Minimum-1)
+				}
+				if field.Maximum != nil {
+					valid = append(valid, *field.Maximum)
+					invalid = append(invalid, *field.Maximum+1)
+				}
+				if field.ExclusiveMinimum != nil {
+					valid = append(valid, *field.ExclusiveMinimum+0.01)
+					invalid = append(invalid, *field.ExclusiveMinimum)
+				}
+				if field.ExclusiveMaximum != nil {
+					valid = append(valid, *field.ExclusiveMaximum-0.01)
+					invalid = append(invalid, *field.ExclusiveMaximum)
+				}
+
+				for _, values := range []struct {
+					values    []interface{}
+					wantValid bool
+				}{{valid, true}, {invalid, false}} {
+					for _, value := range values.values {
+						resp, err := svc.ValidateField(ctx, &model.FieldValidationRequest{Field: field.Name, Value: value})
+						if errors.Is(err, errs.ErrUnknownField) {
+							continue
+						}
+						if err != nil {
+							t.Fatalf("ValidateField(%s) error = %v", field.Name, err)
+						}
+						if resp.Valid != values.wantValid {
+							t.Errorf("ValidateField(%s, %.20v) valid = %t, want %t: %s", field.Name, value, resp.Valid, values.wantValid, resp.Message)
+						}
+					}
+				}
+			}
+
+			description := schemaField(t, schema, "description")
+			cfg := svc.(*carService).cfg
+			if cfg.MaxDescriptionLength > 0 && (description.MaxLength == nil || *description.MaxLength != cfg.MaxDescriptionLength) {
+				t.Errorf("description maxLength = %v, want %d", description.MaxLength, cfg.MaxDescriptionLength)
+			}
+			if cfg.MaxDescriptionLength <= 0 && description.MaxLength != nil {
+				t.Errorf("description maxLength = %d, want none", *description.MaxLength)
+			}
+		})
+	}
+}
+
+// schemaField returns the named field of a schema, failing the test when it is missing
+func schemaField(t *testing.T, schema *model.CarSchemaResponse, name string) model.FieldSchema {
+	t.Helper()
+
+	for _, field := range schema.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	t.Fatalf("schema has no %s field", name)
+	return model.FieldSchema{}
+}
+
+func TestValidateField(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      string
+		value      interface{}
+		closeStore bool
+		wantValid  bool
+		wantErr    error
+	}{
+		{name: "taken name", field: "name", value: "Civic", wantValid: false},
+		{name: "taken name with padding", field: "name", value: " Civic ", wantValid: false},
+		{name: "free name", field: "name", value: "Accord", wantValid: true},
+		{name: "unknown field", field: "color", value: "red", wantErr: errs.ErrUnknownField},
+		{name: "name lookup failure", field: "name", value: "Accord", closeStore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, store := newTestService(t, nil)
+			mustCreateCar(t, svc, carRequest("Civic", "Honda"))
+			if tt.closeStore {
+				store.Close()
+			}
+
+			resp, err := svc.ValidateField(context.Background(), &model.FieldValidationRequest{Field: tt.field, Value: tt.value})
+			if tt.closeStore {
+				// A failed lookup says nothing about whether the name is free
+				if err == nil {
+					t.Fatalf("ValidateField(%s) = %+v, want an error", tt.field, resp)
+				}
+				return
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ValidateField(%s) error = %v, want %v", tt.field, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateField(%s) error = %v", tt.field, err)
+			}
+			if resp.Valid != tt.wantValid {
+				t.Errorf("ValidateField(%s, %v) valid = %t, want %t: %s", tt.field, tt.value, resp.Valid, tt.wantValid, resp.Message)
+			}
+		})
+	}
+}
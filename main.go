@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,12 +15,21 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/swaggo/gin-swagger"
 	"github.com/swaggo/gin-swagger/swaggerFiles"
+	"github.com/swaggo/swag"
 	"github.com/username/go-car-service/internal/api"
 	"github.com/username/go-car-service/internal/config"
+	"github.com/username/go-car-service/internal/jobs"
+	"github.com/username/go-car-service/internal/repository"
 	"github.com/username/go-car-service/pkg/database"
 	"github.com/username/go-car-service/pkg/logger"
+	"github.com/username/go-car-service/pkg/metrics"
+	"github.com/username/go-car-service/pkg/tracing"
 )
 
+// setNameUniquenessCommand is the admin command switching the unique index on car
+// names to the mode selected by UNIQUE_BY_NAME_BRAND, then exiting
+const setNameUniquenessCommand = "set-name-uniqueness"
+
 // @title           Car Service API
 // @version         1.0
 // @description     A car management service API
@@ -29,7 +40,10 @@ import (
 // @license.name  Apache 2.0
 // @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
 // @host      localhost:8080
-// @BasePath  /api/v1
+// @BasePath  /api/v1 (prefixed with BASE_PATH when the spec is served, see swaggerHandler)
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
 	// Load environment variables
 	err := godotenv.Load()
@@ -47,31 +61,81 @@ func main() {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if err := logger.Configure(cfg.LogLevel, cfg.LogFormat); err != nil {
+		logger.Fatalf("Failed to configure logger: %v", err)
+	}
+
+	// Initialize tracing; spans are only exported when an OTLP endpoint is set
+	shutdownTracing, err := tracing.Init(context.Background(), "go-car-service", cfg.OTelExporterEndpoint)
+	if err != nil {
+		logger.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
 	// Initialize database
 	db, err := database.InitDB(cfg)
 	if err != nil {
 		logger.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer db.Close()
+	store := repository.NewStore(db, cfg.DBDriver, repository.ReadRetryPolicy{
+		Attempts:  cfg.DBReadRetryAttempts,
+		BaseDelay: time.Duration(cfg.DBReadRetryBaseDelayMs) * time.Millisecond,
+	}, time.Duration(cfg.SlowQueryThresholdMs)*time.Millisecond)
+	defer store.Close()
 
 	// Run database migrations
-	if err := database.Migrate(db); err != nil {
+	if err := store.Migrate(); err != nil {
 		logger.Fatalf("Failed to run database migrations: %v", err)
 	}
 
-	// Initialize Gin router
-	r := gin.Default()
+	// Names of active cars are unique on their own, or per brand. Switching swaps
+	// a unique index, so it is a one-off admin command rather than part of every start.
+	if len(os.Args) > 1 && os.Args[1] == setNameUniquenessCommand {
+		if err := store.SetNameUniqueness(context.Background(), cfg.UniqueByNameBrand); err != nil {
+			logger.Fatalf("Failed to set car name uniqueness: %v", err)
+		}
+		logger.Infof("Car name uniqueness set (unique per brand: %t)", cfg.UniqueByNameBrand)
+		return
+	}
+	if cfg.UniqueByNameBrand {
+		logger.Info("Car names are unique per brand")
+	} else {
+		logger.Info("Car names are unique across brands")
+	}
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// The jobs share the handlers' service, so their changes are audited,
+	// published and reflected in the cache
+	publisher := api.NewEventPublisher(cfg)
+	carService := api.NewCarService(store, cfg, publisher)
+	go jobs.RunPeriodic(jobsCtx, "scheduled-deletions", time.Duration(cfg.ScheduledDeletionIntervalSeconds)*time.Second, func(ctx context.Context) error {
+		_, err := carService.ProcessScheduledDeletions(ctx)
+		return err
+	})
+	go jobs.RunPeriodic(jobsCtx, "purge-deleted", time.Duration(cfg.PurgeIntervalHours)*time.Hour, func(ctx context.Context) error {
+		_, err := carService.PurgeDeletedCars(ctx)
+		return err
+	})
+	go jobs.RunPeriodic(jobsCtx, "purge-idempotency-keys", time.Duration(cfg.IdempotencyPurgeIntervalMinutes)*time.Minute, func(ctx context.Context) error {
+		_, err := carService.PurgeIdempotencyKeys(ctx)
+		return err
+	})
+
+	// Initialize Gin router; logging and recovery are registered by SetupRouter
+	r := gin.New()
 
 	// Setup routes
-	api.SetupRouter(r, db)
+	api.SetupRouter(r, store, cfg, carService)
 
 
 	// Swagger
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET(cfg.BasePath+"/swagger/*any", swaggerHandler(cfg.BasePath))
 
 	// Start server
 	srv := &http.Server{
-		Addr:    ":" + cfg.ServerPort,
+		Addr:    net.JoinHostPort(cfg.ServerHost, cfg.ServerPort),
 		Handler: r,
 	}
 
@@ -82,23 +146,73 @@ func main() {
 		}
 	}()
 
-	logger.Infof("Server is running on port %s", cfg.ServerPort)
+	logger.Infof("Server is listening on %s", srv.Addr)
 
 
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	logger.Info("Shutting down server...")
+	logger.Infof("Shutting down server with %d requests in flight...", metrics.InFlightRequests())
 
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Answer new requests with 503 from now on, and give load balancers time to
+	// notice before the listener closes
+	api.BeginShutdown()
+	if cfg.ShutdownDrainSeconds > 0 {
+		time.Sleep(time.Duration(cfg.ShutdownDrainSeconds) * time.Second)
+	}
+
+	// Stop background jobs before draining the server
+	stopJobs()
+
+	// The context is used to inform the server how long it has to finish
+	// the requests it is currently handling
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Let the webhook deliveries still in flight finish
+	if err := publisher.Close(ctx); err != nil {
+		logger.Errorf("Failed to deliver every event: %v", err)
+	}
+
+	// Flush the spans still buffered
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Errorf("Failed to flush traces: %v", err)
+	}
+
 	logger.Info("Server exited properly")
 }
+
+// swaggerHandler serves the Swagger UI and its spec. The spec's base path is set
+// at runtime to the API's path under basePath, since the generated one only
+// knows the @BasePath annotation.
+func swaggerHandler(basePath string) gin.HandlerFunc {
+	ui := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL(basePath+"/swagger/doc.json"))
+
+	return func(c *gin.Context) {
+		if c.Param("any") != "/doc.json" {
+			ui(c)
+			return
+		}
+
+		doc, err := swag.ReadDoc()
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Swagger docs have not been generated"})
+			return
+		}
+
+		var spec map[string]interface{}
+		if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+			logger.Errorf("Failed to decode Swagger docs: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid Swagger docs"})
+			return
+		}
+		spec["basePath"] = basePath + "/api/v1"
+
+		c.JSON(http.StatusOK, spec)
+	}
+}
@@ -0,0 +1,6 @@
+-- Add scheduled deletion timestamp to cars
+ALTER TABLE cars ADD COLUMN IF NOT EXISTS delete_scheduled_at TIMESTAMP WITH TIME ZONE;
+
+-- Create index used by the scheduled deletion job
+CREATE INDEX IF NOT EXISTS idx_cars_delete_scheduled_at ON cars(delete_scheduled_at)
+WHERE deleted_at IS NULL AND delete_scheduled_at IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Create audit log table
+-- There is deliberately no foreign key to cars so history outlives purged cars
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    car_id BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    actor VARCHAR(255),
+    details JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_audit_log_car_id ON audit_log(car_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_event_type ON audit_log(event_type, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
@@ -0,0 +1,5 @@
+-- Add nullable model year to cars
+ALTER TABLE cars ADD COLUMN IF NOT EXISTS year INT CHECK (year BETWEEN 1900 AND 2100);
+
+-- Create index used by year filters
+CREATE INDEX IF NOT EXISTS idx_cars_year ON cars(year) WHERE deleted_at IS NULL;
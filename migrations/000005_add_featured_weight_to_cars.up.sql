@@ -0,0 +1,6 @@
+-- Add featured flag and selection weight to cars
+ALTER TABLE cars ADD COLUMN IF NOT EXISTS featured BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE cars ADD COLUMN IF NOT EXISTS weight INT NOT NULL DEFAULT 1 CHECK (weight > 0);
+
+-- Create index used by featured car selection
+CREATE INDEX IF NOT EXISTS idx_cars_featured ON cars(featured) WHERE deleted_at IS NULL AND featured;
@@ -0,0 +1,2 @@
+-- Add version column used for optimistic locking of updates
+ALTER TABLE cars ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,14 @@
+-- Enforce unique names among active cars; the application checks too, but
+-- only the database can rule out two concurrent creates with the same name
+
+-- Active cars sharing a name would make the index fail, so all but the oldest
+-- of each name get their ID appended, e.g. "Model S (42)"
+UPDATE cars
+SET name = LEFT(name, 100 - LENGTH(' (' || id || ')')) || ' (' || id || ')'
+WHERE deleted_at IS NULL
+    AND id NOT IN (SELECT MIN(id) FROM cars WHERE deleted_at IS NULL GROUP BY name);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cars_name_unique ON cars(name) WHERE deleted_at IS NULL;
+
+-- The plain index is superseded by the unique one
+DROP INDEX IF EXISTS idx_cars_name;
@@ -0,0 +1,5 @@
+-- Add optional color to cars
+ALTER TABLE cars ADD COLUMN IF NOT EXISTS color VARCHAR(50);
+
+-- Create index used by the case-insensitive color lookup
+CREATE INDEX IF NOT EXISTS idx_cars_color ON cars(LOWER(color)) WHERE deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- Idempotency keys are scoped to the client sending them and keep the original
+-- response, so retries replay it. A key is reserved before its car is created,
+-- hence the nullable car and response. Existing keys can't be attributed to a
+-- client, so they are dropped.
+DROP TABLE IF EXISTS idempotency_keys;
+
+CREATE TABLE idempotency_keys (
+    client TEXT NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    car_id BIGINT REFERENCES cars(id) ON DELETE CASCADE,
+    response TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (client, key)
+);
+
+-- Create index used to purge expired keys
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
@@ -0,0 +1,39 @@
+-- SQLite schema for local development and tests, equivalent to the PostgreSQL
+-- migrations in the parent directory. Keep both in sync when the schema changes.
+CREATE TABLE IF NOT EXISTS cars (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    brand VARCHAR(100) NOT NULL,
+    manufacturing_value DECIMAL(15, 2) NOT NULL CHECK (manufacturing_value > 0 AND manufacturing_value < 15000000),
+    description TEXT,
+    year INTEGER CHECK (year BETWEEN 1900 AND 2100),
+    featured BOOLEAN NOT NULL DEFAULT FALSE,
+    weight INTEGER NOT NULL DEFAULT 1 CHECK (weight > 0),
+    version INTEGER NOT NULL DEFAULT 1,
+    delete_scheduled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cars_name_unique ON cars(name) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_cars_brand ON cars(brand) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_cars_year ON cars(year) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    car_id INTEGER NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    actor VARCHAR(255),
+    details TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_car_id ON audit_log(car_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_event_type ON audit_log(event_type, id);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    car_id INTEGER NOT NULL REFERENCES cars(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_cars_color;
+
+ALTER TABLE cars DROP COLUMN color;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_cars_created_at;
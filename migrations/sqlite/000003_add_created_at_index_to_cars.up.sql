@@ -0,0 +1,2 @@
+-- Create index used by the created-on-date lookup
+CREATE INDEX IF NOT EXISTS idx_cars_created_at ON cars(created_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_cars_brand ON cars(brand) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS idx_cars_brand_lower;
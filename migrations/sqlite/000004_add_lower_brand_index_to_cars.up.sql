@@ -0,0 +1,5 @@
+-- Brands are matched case-insensitively, so the lookups filter on LOWER(brand)
+CREATE INDEX IF NOT EXISTS idx_cars_brand_lower ON cars(LOWER(brand)) WHERE deleted_at IS NULL;
+
+-- The plain index is superseded by the one on the lowercased brand
+DROP INDEX IF EXISTS idx_cars_brand;
@@ -0,0 +1,15 @@
+-- Idempotency keys are scoped to the client sending them and keep the original
+-- response, so retries replay it. Existing keys can't be attributed to a client,
+-- so they are dropped.
+DROP TABLE IF EXISTS idempotency_keys;
+
+CREATE TABLE idempotency_keys (
+    client TEXT NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    car_id INTEGER REFERENCES cars(id) ON DELETE CASCADE,
+    response TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (client, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
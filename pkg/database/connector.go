@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// initConnector wraps a driver.Connector and runs init on every new connection
+// before it is handed to the pool
+type initConnector struct {
+	driver.Connector
+	init func(ctx context.Context, conn driver.Conn) error
+}
+
+// Connect opens a connection and runs the init hook on it
+func (c *initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.init(ctx, conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialize connection: %w", err)
+	}
+
+	return conn, nil
+}
+
+// setStatementTimeout returns a connection init hook that sets the Postgres
+// statement_timeout, so the server cancels any statement running longer than timeoutMs
+func setStatementTimeout(timeoutMs int) func(ctx context.Context, conn driver.Conn) error {
+	return func(ctx context.Context, conn driver.Conn) error {
+		execer, ok := conn.(driver.ExecerContext)
+		if !ok {
+			return errors.New("driver connection does not support ExecContext")
+		}
+
+		_, err := execer.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = %d", timeoutMs), nil)
+		return err
+	}
+}
+
+// discardConn closes conn for good instead of returning it to the pool, for
+// connections whose session settings were changed
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+}
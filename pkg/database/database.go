@@ -1,26 +1,76 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+
 	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/username/go-car-service/internal/config"
 	"github.com/username/go-car-service/pkg/logger"
 )
 
-// InitDB initializes the database connection
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+)
+
+// InitDB initializes the database connection for the configured driver
 func InitDB(cfg *config.Config) (*sql.DB, error) {
+	switch cfg.DBDriver {
+	case DriverPostgres:
+		return initPostgres(cfg)
+	case DriverSQLite:
+		return initSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.DBDriver)
+	}
+}
+
+// initPostgres connects to PostgreSQL
+func initPostgres(cfg *config.Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode)
 
-	db, err := sql.Open("postgres", dsn)
+	// Certificate paths are only passed when set, so a plain sslmode=disable keeps working.
+	// They are quoted as paths may contain spaces.
+	if cfg.DBSSLRootCert != "" {
+		dsn += " sslrootcert=" + quoteDSNValue(cfg.DBSSLRootCert)
+	}
+	if cfg.DBSSLCert != "" {
+		dsn += " sslcert=" + quoteDSNValue(cfg.DBSSLCert)
+	}
+	if cfg.DBSSLKey != "" {
+		dsn += " sslkey=" + quoteDSNValue(cfg.DBSSLKey)
+	}
+
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 
+	// Have the server cancel runaway statements on every pooled connection
+	var db *sql.DB
+	if cfg.DBStatementTimeoutMs > 0 {
+		db = sql.OpenDB(&initConnector{
+			Connector: connector,
+			init:      setStatementTimeout(cfg.DBStatementTimeoutMs),
+		})
+	} else {
+		db = sql.OpenDB(connector)
+	}
+
 	// Test the connection
 	if err = db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %v", err)
@@ -30,16 +80,69 @@ func InitDB(cfg *config.Config) (*sql.DB, error) {
 	return db, nil
 }
 
-// Migrate runs database migrations
-func Migrate(db *sql.DB) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+// quoteDSNValue quotes a value for a key/value connection string, escaping
+// backslashes and single quotes
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// initSQLite opens the SQLite database named by DB_NAME, e.g. a file path or
+// "file::memory:?cache=shared" for an in-memory database
+func initSQLite(cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open(DriverSQLite, cfg.DBName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// SQLite allows a single writer; one connection also keeps an in-memory database alive
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %v", err)
+	}
+
+	if _, err = db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return nil, fmt.Errorf("failed to enable foreign keys: %v", err)
+	}
+
+	logger.Info("Successfully opened SQLite database")
+	return db, nil
+}
+
+// Migrate runs database migrations for the given driver. SQLite uses its own
+// migrations under migrations/sqlite, as the PostgreSQL ones rely on Postgres-only features.
+func Migrate(db *sql.DB, driverName string) error {
+	sourceURL, err := migrationsSource(driverName)
+	if err != nil {
+		return err
+	}
+
+	var driver migratedb.Driver
+	switch driverName {
+	case DriverPostgres:
+		// Migrations may build indexes on large tables, so they run on a connection
+		// of their own without the statement timeout, discarded once they are done
+		ctx := context.Background()
+		conn, connErr := db.Conn(ctx)
+		if connErr != nil {
+			return fmt.Errorf("failed to get migration connection: %v", connErr)
+		}
+		defer discardConn(conn)
+
+		if _, err = conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+			return fmt.Errorf("failed to lift statement timeout for migrations: %v", err)
+		}
+		driver, err = postgres.WithConnection(ctx, conn, &postgres.Config{})
+	case DriverSQLite:
+		driver, err = sqlite3.WithInstance(db, &sqlite3.Config{})
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create migration driver: %v", err)
 	}
 
 	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres",
+		sourceURL,
+		driverName,
 		driver,
 	)
 	if err != nil {
@@ -53,3 +156,81 @@ func Migrate(db *sql.DB) error {
 	logger.Info("Database migrations completed successfully")
 	return nil
 }
+
+// migrationsSource returns the location of the migrations for a driver
+func migrationsSource(driverName string) (string, error) {
+	switch driverName {
+	case DriverPostgres:
+		return "file://migrations", nil
+	case DriverSQLite:
+		return "file://migrations/sqlite", nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q", driverName)
+	}
+}
+
+// MigrationStatus describes how far the database schema is migrated
+type MigrationStatus struct {
+	Current  uint `json:"current"`
+	Expected uint `json:"expected"`
+	Dirty    bool `json:"dirty"`
+}
+
+// UpToDate reports whether every expected migration is cleanly applied
+func (s *MigrationStatus) UpToDate() bool {
+	return !s.Dirty && s.Current >= s.Expected
+}
+
+// GetMigrationStatus compares the version recorded in schema_migrations with the
+// latest migration shipped with the application. It only reads, so it is safe to
+// call while serving requests.
+func GetMigrationStatus(ctx context.Context, db *sql.DB, driverName string) (*MigrationStatus, error) {
+	expected, err := latestMigrationVersion(driverName)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Expected: expected}
+
+	var current int64
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&current, &status.Dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	status.Current = uint(current)
+
+	return status, nil
+}
+
+// latestMigrationVersion returns the version of the last migration for a driver
+func latestMigrationVersion(driverName string) (uint, error) {
+	sourceURL, err := migrationsSource(driverName)
+	if err != nil {
+		return 0, err
+	}
+
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,16 +16,17 @@ import (
 
 var log *logrus.Logger
 
+type contextKey string
+
+// requestIDKey is the context key under which the request ID is stored
+const requestIDKey contextKey = "request_id"
+
 // InitLogger initializes the logger
 func InitLogger() {
 	log = logrus.New()
 	log.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: "2006-01-02 15:04:05",
-		CallerPrettyfier: func(f *runtime.Frame) (string, string) {
-			// Get the file name and line number
-			filename := filepath.Base(f.File)
-			return "", fmt.Sprintf("%s:%d", filename, f.Line)
-		},
+		TimestampFormat:  "2006-01-02 15:04:05",
+		CallerPrettyfier: callerPrettyfier,
 	})
 
 	// Set output to stdout by default
@@ -33,6 +36,39 @@ func InitLogger() {
 	log.SetReportCaller(true)
 }
 
+// Configure sets the minimum level logged (debug, info, warn or error) and the
+// output format (json or text). It is meant to be called once the configuration is loaded.
+func Configure(level, format string) error {
+	parsedLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		// InitLogger already sets the JSON formatter
+	case "text":
+		log.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp:    true,
+			TimestampFormat:  "2006-01-02 15:04:05",
+			CallerPrettyfier: callerPrettyfier,
+		})
+	default:
+		return fmt.Errorf("invalid log format %q: must be json or text", format)
+	}
+
+	log.SetLevel(parsedLevel)
+
+	return nil
+}
+
+// callerPrettyfier reports the caller as file:line only
+func callerPrettyfier(f *runtime.Frame) (string, string) {
+	// Get the file name and line number
+	filename := filepath.Base(f.File)
+	return "", fmt.Sprintf("%s:%d", filename, f.Line)
+}
+
 // SetOutput sets the output destination for the logger
 func SetOutput(w io.Writer) {
 	log.SetOutput(w)
@@ -103,6 +139,29 @@ func WithError(err error) *logrus.Entry {
 	return log.WithError(err)
 }
 
+// ContextWithRequestID returns a copy of ctx carrying the given request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithRequestID returns a log entry tagged with the request ID stored in ctx, if any
+func WithRequestID(ctx context.Context) *logrus.Entry {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return log.WithField("request_id", requestID)
+	}
+	return logrus.NewEntry(log)
+}
+
 // GetLogger returns the underlying logger instance
 func GetLogger() *logrus.Logger {
 	return log
@@ -128,21 +187,39 @@ func LogRequest(method, path string, status int, latency time.Duration, clientIP
 }
 
 // LogSQLError logs an SQL error with context
-func LogSQLError(err error, query string, args ...interface{}) {
-	// Limit the length of the query and args in logs
-	safeQuery := query
-	if len(safeQuery) > 1000 {
-		safeQuery = safeQuery[:1000] + "..."
+// Queries abandoned because the request was canceled or timed out are only logged at debug level.
+func LogSQLError(ctx context.Context, err error, query string, args ...interface{}) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		WithRequestID(ctx).Debugf("Query abandoned: %v", err)
+		return
 	}
 
+	safeQuery := truncateQuery(query)
+
 	// Convert args to string representation
 	argsStr := make([]string, 0, len(args))
 	for _, arg := range args {
 		argsStr = append(argsStr, fmt.Sprintf("%v", arg))
 	}
 
-	log.WithFields(logrus.Fields{
+	WithRequestID(ctx).WithFields(logrus.Fields{
 		"query": safeQuery,
 		"args":  argsStr,
 	}).Errorf("SQL error: %v", err)
 }
+
+// LogSlowQuery logs a query that took longer than the slow query threshold
+func LogSlowQuery(ctx context.Context, query string, duration time.Duration) {
+	WithRequestID(ctx).WithFields(logrus.Fields{
+		"query":    truncateQuery(query),
+		"duration": duration.String(),
+	}).Warnf("Slow query took %s", duration)
+}
+
+// truncateQuery limits the length of a query in logs
+func truncateQuery(query string) string {
+	if len(query) > 1000 {
+		return query[:1000] + "..."
+	}
+	return query
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests processed.",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	// inFlight is kept alongside the gauge so it can be read without a scrape
+	inFlight atomic.Int64
+
+	requestsInFlight = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being processed.",
+		},
+		func() float64 { return float64(inFlight.Load()) },
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestsInFlight, requestDuration)
+}
+
+// RequestStarted marks a request as in flight until RequestFinished is called
+func RequestStarted() {
+	inFlight.Add(1)
+}
+
+// RequestFinished marks a request started with RequestStarted as done
+func RequestFinished() {
+	inFlight.Add(-1)
+}
+
+// InFlightRequests returns the number of requests currently being processed
+func InFlightRequests() int64 {
+	return inFlight.Load()
+}
+
+// ObserveRequest records a processed HTTP request. Route should be the route
+// pattern (e.g. /api/v1/cars/:id) rather than the raw path to keep label cardinality low.
+func ObserveRequest(method, route string, status int, latency time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	requestsTotal.WithLabelValues(method, route, statusLabel).Inc()
+	requestDuration.WithLabelValues(method, route, statusLabel).Observe(latency.Seconds())
+}
+
+// RegisterDBStats exposes connection pool usage as gauges, sampled from
+// stats on every scrape
+func RegisterDBStats(stats func() sql.DBStats) {
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "db_connections_in_use",
+				Help: "Number of database connections currently in use.",
+			},
+			func() float64 { return float64(stats().InUse) },
+		),
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "db_connections_idle",
+				Help: "Number of idle database connections.",
+			},
+			func() float64 { return float64(stats().Idle) },
+		),
+	)
+}